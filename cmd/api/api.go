@@ -13,7 +13,9 @@ import (
 	"github.com/andras-szesztai/social/docs"
 	"github.com/andras-szesztai/social/internal/auth"
 	"github.com/andras-szesztai/social/internal/mailer"
+	"github.com/andras-szesztai/social/internal/oidc"
 	"github.com/andras-szesztai/social/internal/ratelimiter"
+	"github.com/andras-szesztai/social/internal/storage"
 	"github.com/andras-szesztai/social/internal/store"
 	"github.com/andras-szesztai/social/internal/store/cache"
 	"github.com/go-chi/chi/v5"
@@ -24,13 +26,16 @@ import (
 )
 
 type application struct {
-	config        config
-	store         *store.Store
-	logger        *zap.SugaredLogger
-	mailer        mailer.Client
-	cache         *cache.Storage
-	authenticator auth.Authenticator
-	rateLimiter   *ratelimiter.FixedWindowLimiter
+	config         config
+	store          *store.Store
+	logger         *zap.SugaredLogger
+	mailer         mailer.Client
+	cache          *cache.Storage
+	authenticator  auth.Authenticator
+	rateLimiter    *ratelimiter.FixedWindowLimiter
+	oidcProviders  map[string]oidc.Provider
+	passwordPolicy *auth.PasswordPolicy
+	storage        storage.Storage
 }
 
 type config struct {
@@ -43,12 +48,45 @@ type config struct {
 	auth        authConfig
 	redis       redisConfig
 	rateLimiter ratelimiter.Config
+	jobs        jobsConfig
+	storage     storageConfig
+	media       mediaConfig
+}
+
+type storageConfig struct {
+	backend       string
+	localDir      string
+	signingSecret string
+	s3            storage.S3Config
+}
+
+type mediaConfig struct {
+	baseURL       string
+	maxUploadSize int64
+	maxPixels     int
+	urlExpiry     time.Duration
+}
+
+type jobsConfig struct {
+	purgeInterval    time.Duration
+	unactivatedGrace time.Duration
+	// publishInterval is how often scheduled posts are checked for being
+	// due.
+	publishInterval time.Duration
+	// restoreWindow is how long a deactivated account can be restored by
+	// signing in before purgePolicy is applied to it.
+	restoreWindow time.Duration
+	purgePolicy   store.PurgePolicy
 }
 
 type mailConfig struct {
-	expiry time.Duration
-	apiKey string
-	from   string
+	expiry            time.Duration
+	resendCooldown    time.Duration
+	resetExpiry       time.Duration
+	emailChangeExpiry time.Duration
+	emailRevertWindow time.Duration
+	apiKey            string
+	from              string
 }
 
 type dbConfig struct {
@@ -59,8 +97,28 @@ type dbConfig struct {
 }
 
 type authConfig struct {
-	basic basicAuthConfig
-	token tokenConfig
+	basic     basicAuthConfig
+	token     tokenConfig
+	mfa       mfaConfig
+	lockout   lockoutConfig
+	magicLink magicLinkConfig
+	oidc      oidcConfig
+	password  passwordConfig
+}
+
+type passwordConfig struct {
+	minLength         int
+	blocklistFile     string
+	hasher            string
+	bcryptCost        int
+	argon2Memory      int
+	argon2Iterations  int
+	argon2Parallelism int
+}
+
+type magicLinkConfig struct {
+	enabled bool
+	exp     time.Duration
 }
 
 type redisConfig struct {
@@ -76,10 +134,20 @@ type basicAuthConfig struct {
 }
 
 type tokenConfig struct {
-	secret string
-	exp    time.Duration
-	aud    string
-	iss    string
+	secret     string
+	keysFile   string
+	keyGrace   time.Duration
+	exp        time.Duration
+	refreshExp time.Duration
+	aud        string
+	iss        string
+}
+
+type mfaConfig struct {
+	issuer       string
+	challengeExp time.Duration
+	requiredRole string
+	maxAttempts  int
 }
 
 type contextKey string
@@ -102,6 +170,8 @@ func (app *application) mountRoutes() http.Handler {
 	}))
 	router.Use(app.RateLimiterMiddleware)
 
+	router.Get("/.well-known/jwks.json", app.jwksHandler)
+
 	router.Route("/v1", func(r chi.Router) {
 		r.Get("/healthcheck", app.healthCheckHandler)
 		r.With(app.BasicAuthMiddleware).Get("/debug/vars", expvar.Handler().ServeHTTP)
@@ -111,35 +181,118 @@ func (app *application) mountRoutes() http.Handler {
 
 		r.Route("/posts", func(r chi.Router) {
 			r.Use(app.AuthTokenMiddleware)
-			r.Post("/", app.createPostHandler)
+			r.With(app.requireScope(scopePostsWrite)).Post("/", app.createPostHandler)
 
 			r.Route("/{id}", func(r chi.Router) {
 				r.Use(app.postsContextMiddleware)
-				r.Get("/", app.getPostHandler)
-				r.Patch("/", app.checkPostOwnership("moderator", app.updatePostHandler))
-				r.Delete("/", app.checkPostOwnership("admin", app.deletePostHandler))
+				r.With(app.requireScope(scopePostsRead)).Get("/", app.getPostHandler)
+				r.With(app.requireScope(scopePostsWrite)).Patch("/", app.checkPostOwnership("moderator", app.updatePostHandler))
+				r.With(app.requireScope(scopePostsWrite)).Delete("/", app.checkPostOwnership("admin", app.deletePostHandler))
 
 				r.Route("/comments", func(r chi.Router) {
-					r.Get("/", app.getCommentsByPostIDHandler)
-					r.Post("/", app.createCommentHandler)
+					r.With(app.requireScope(scopePostsRead)).Get("/", app.getCommentsByPostIDHandler)
+					r.With(app.requireScope(scopeCommentsWrite)).Post("/", app.createCommentHandler)
+				})
+
+				r.Route("/revisions", func(r chi.Router) {
+					r.With(app.requireScope(scopePostsRead)).Get("/", app.getPostRevisionsHandler)
+					r.With(app.requireScope(scopePostsRead)).Get("/{version}", app.getPostRevisionHandler)
+					r.With(app.requireScope(scopePostsWrite)).Post("/{version}/restore", app.checkPostOwnership("moderator", app.restorePostRevisionHandler))
+				})
+
+				r.With(app.requireScope(scopePostsWrite)).Post("/repost", app.repostHandler)
+				r.With(app.requireScope(scopePostsWrite)).Delete("/repost", app.undoRepostHandler)
+
+				r.Route("/reactions", func(r chi.Router) {
+					r.With(app.requireScope(scopePostsRead)).Get("/", app.getReactionsHandler)
+					r.With(app.requireScope(scopeReactionsWrite)).Post("/", app.addReactionHandler)
+					r.With(app.requireScope(scopeReactionsWrite)).Delete("/{type}", app.removeReactionHandler)
 				})
 			})
 		})
 
+		r.Route("/media", func(r chi.Router) {
+			if local, ok := app.storage.(*storage.LocalStorage); ok {
+				r.Handle("/files/*", http.StripPrefix("/v1/media/files", local))
+			}
+			r.With(app.OptionalAuthTokenMiddleware).Get("/{id}/content", app.getMediaContentHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware)
+				r.With(app.requireScope(scopePostsWrite)).Post("/", app.uploadMediaHandler)
+				r.With(app.requireScope(scopePostsRead)).Get("/{id}", app.getMediaHandler)
+				r.With(app.requireScope(scopePostsWrite)).Delete("/{id}", app.deleteMediaHandler)
+			})
+		})
+
 		r.Route("/comments", func(r chi.Router) {
 			r.Use(app.AuthTokenMiddleware)
 			r.Route("/{id}", func(r chi.Router) {
 				r.Use(app.commentsContextMiddleware)
-				r.Get("/", app.getCommentHandler)
-				r.Patch("/", app.updateCommentHandler)
-				r.Delete("/", app.deleteCommentHandler)
+				r.With(app.requireScope(scopePostsRead)).Get("/", app.getCommentHandler)
+				r.With(app.requireScope(scopeCommentsWrite)).Patch("/", app.updateCommentHandler)
+				r.With(app.requireScope(scopeCommentsWrite)).Delete("/", app.deleteCommentHandler)
 			})
 		})
 
 		r.Route("/users", func(r chi.Router) {
 			r.Group(func(r chi.Router) {
 				r.Use(app.AuthTokenMiddleware)
-				r.Get("/feed", app.getUserFeedHandler)
+				r.With(app.requireScope(scopeFeedRead)).Get("/feed", app.getUserFeedHandler)
+			})
+
+			r.Route("/me", func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware)
+
+				r.Route("/identities", func(r chi.Router) {
+					r.Use(app.requireSession)
+					r.Get("/", app.getIdentitiesHandler)
+					r.Post("/{provider}", app.linkIdentityHandler)
+					r.Post("/{provider}/callback", app.linkIdentityCallbackHandler)
+					r.Delete("/{identityID}", app.unlinkIdentityHandler)
+				})
+
+				r.With(app.requireScope(scopeUsersRead)).Get("/profile", app.getProfileHandler)
+				r.With(app.requireScope(scopeUsersWrite)).Patch("/profile", app.updateProfileHandler)
+				r.With(app.requireScope(scopeUsersWrite)).Put("/avatar", app.uploadAvatarHandler)
+				r.With(app.requireScope(scopePostsRead)).Get("/drafts", app.getDraftsHandler)
+				r.With(app.requireScope(scopeUsersRead)).Get("/blocks", app.getBlockedUsersHandler)
+				r.With(app.requireScope(scopeUsersRead)).Get("/mutes", app.getMutedUsersHandler)
+
+				r.Route("/bookmarks", func(r chi.Router) {
+					r.With(app.requireScope(scopeUsersRead)).Get("/", app.getBookmarksHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Post("/", app.addBookmarkHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Patch("/{postID}", app.moveBookmarkHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Delete("/{postID}", app.removeBookmarkHandler)
+
+					r.Route("/folders", func(r chi.Router) {
+						r.With(app.requireScope(scopeUsersRead)).Get("/", app.getBookmarkFoldersHandler)
+						r.With(app.requireScope(scopeUsersWrite)).Post("/", app.createBookmarkFolderHandler)
+						r.With(app.requireScope(scopeUsersWrite)).Delete("/{folderID}", app.deleteBookmarkFolderHandler)
+					})
+				})
+
+				r.Route("/follow-requests", func(r chi.Router) {
+					r.With(app.requireScope(scopeUsersRead)).Get("/", app.getFollowRequestsHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Post("/{requesterID}/approve", app.approveFollowRequestHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Post("/{requesterID}/reject", app.rejectFollowRequestHandler)
+				})
+				r.With(app.requireSession).Patch("/email", app.changeEmailHandler)
+				r.With(app.requireSession).Put("/password", app.changePasswordHandler)
+				r.With(app.requireSession).Delete("/", app.deleteAccountHandler)
+
+				r.Route("/sessions", func(r chi.Router) {
+					r.Use(app.requireSession)
+					r.Get("/", app.getSessionsHandler)
+					r.Delete("/{sessionID}", app.revokeSessionHandler)
+				})
+
+				r.Route("/tokens", func(r chi.Router) {
+					r.Use(app.requireSession)
+					r.Get("/", app.getPersonalAccessTokensHandler)
+					r.Post("/", app.createPersonalAccessTokenHandler)
+					r.Delete("/{tokenID}", app.revokePersonalAccessTokenHandler)
+				})
 			})
 
 			r.Route("/{id}", func(r chi.Router) {
@@ -148,17 +301,57 @@ func (app *application) mountRoutes() http.Handler {
 
 				r.Group(func(r chi.Router) {
 					r.Use(app.AuthTokenMiddleware)
-					r.Get("/", app.getUserHandler)
-					r.Post("/follow", app.followUserHandler)
-					r.Post("/unfollow", app.unfollowUserHandler)
-					r.Delete("/", app.deleteUserHandler)
+					r.With(app.requireScope(scopeUsersRead)).Get("/", app.getUserHandler)
+					r.With(app.requireScope(scopeUsersRead)).Get("/followers", app.getFollowersHandler)
+					r.With(app.requireScope(scopeUsersRead)).Get("/following", app.getFollowingHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Post("/follow", app.followUserHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Post("/unfollow", app.unfollowUserHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Post("/block", app.blockUserHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Post("/unblock", app.unblockUserHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Post("/mute", app.muteUserHandler)
+					r.With(app.requireScope(scopeUsersWrite)).Post("/unmute", app.unmuteUserHandler)
+					r.With(app.requireSession).Delete("/", app.deleteUserHandler)
 				})
 			})
 		})
 
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware, app.requireSession, app.requireRole("admin"))
+			r.Get("/lockouts", app.getLockoutsHandler)
+			r.Delete("/lockouts/{id}", app.clearLockoutHandler)
+		})
+
 		r.Route("/authentication", func(r chi.Router) {
 			r.Post("/register", app.registerUserHandler)
+			r.Post("/activation/resend", app.resendActivationHandler)
 			r.Post("/token", app.createTokenHandler)
+			r.Post("/refresh", app.refreshTokenHandler)
+			r.Post("/password/forgot", app.forgotPasswordHandler)
+			r.Post("/password/reset", app.resetPasswordHandler)
+			r.Post("/magic-link", app.requestMagicLinkHandler)
+			r.Post("/magic-link/redeem", app.redeemMagicLinkHandler)
+			r.Post("/email/confirm", app.confirmEmailChangeHandler)
+			r.Post("/email/revert", app.revertEmailChangeHandler)
+			r.Post("/oidc/{provider}/login", app.oidcLoginHandler)
+			r.Post("/oidc/{provider}/callback", app.oidcCallbackHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware, app.requireSession)
+				r.Post("/logout", app.logoutHandler)
+				r.Post("/logout/all", app.logoutAllHandler)
+			})
+
+			r.Route("/mfa", func(r chi.Router) {
+				r.Post("/verify", app.verifyMFAHandler)
+
+				r.Group(func(r chi.Router) {
+					r.Use(app.MFAEnrollmentAuthMiddleware, app.requireSession)
+					r.Post("/totp", app.enrollTOTPHandler)
+					r.Post("/totp/confirm", app.confirmTOTPHandler)
+				})
+
+				r.With(app.AuthTokenMiddleware, app.requireSession).Post("/totp/disable", app.disableTOTPHandler)
+			})
 		})
 	})
 
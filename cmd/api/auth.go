@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -17,7 +19,7 @@ import (
 type RegisterUserPayload struct {
 	Username string `json:"username" validate:"required,min=3,max=20"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8,max=72"`
+	Password string `json:"password" validate:"required,max=72"`
 }
 
 // RegisterUser godoc
@@ -44,6 +46,11 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if err := app.passwordPolicy.Validate(payload.Password, payload.Username, payload.Email); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
 	user := &store.User{
 		Username: payload.Username,
 		Email:    payload.Email,
@@ -52,18 +59,13 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		},
 	}
 
-	if err := user.Password.Set(payload.Password); err != nil {
-		app.internalServerError(w, r, err)
-		return
-	}
+	user.Password.Set(payload.Password)
 
 	ctx := r.Context()
 
 	token := uuid.New().String()
-	hash := sha256.Sum256([]byte(token))
-	hashToken := hex.EncodeToString(hash[:])
 
-	err := app.store.Users.CreateAndInvite(ctx, user, hashToken, app.config.mail.expiry)
+	err := app.store.Users.CreateAndInvite(ctx, user, hashToken(token), app.config.mail.expiry)
 	if err != nil {
 		switch err {
 		case store.ErrEmailAlreadyExists:
@@ -87,6 +89,7 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 			app.logger.Error("failed to rollback user creation", "error", err)
 		}
 		app.internalServerError(w, r, err)
+		return
 	}
 
 	if err := app.jsonResponse(w, http.StatusCreated, nil); err != nil {
@@ -95,22 +98,77 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+type ResendActivationPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResendActivation godoc
+//
+//	@Summary		Resend activation email
+//	@Description	Replace the pending invitation of a not yet activated account with a new one and email it. Resends are throttled per account.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	ResendActivationPayload	true	"Resend activation payload"
+//	@Success		202		"Activation email sent if the account is awaiting activation"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Router			/authentication/activation/resend [post]
+func (app *application) resendActivationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ResendActivationPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	token := uuid.New().String()
+
+	// Unknown emails, activated accounts and throttled resends all get the
+	// same response so the endpoint cannot be used to enumerate accounts.
+	user, err := app.store.Users.RotateInvitation(r.Context(), payload.Email, hashToken(token), app.config.mail.expiry, app.config.mail.resendCooldown)
+	switch err {
+	case nil:
+		err = app.mailer.Send(mailer.UserInvitationTemplate, user.Username, user.Email, map[string]any{
+			"Username":      user.Username,
+			"ActivationURL": fmt.Sprintf("%s/confirm/%s", app.config.frontendURL, token),
+		}, app.config.env == "production")
+		if err != nil {
+			app.logger.Errorw("failed to resend user invitation email", "error", err)
+		}
+	case sql.ErrNoRows, store.ErrInvitationCooldown:
+	default:
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusAccepted, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
 type CreateTokenPayload struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8,max=72"`
+	Password string `json:"password" validate:"required,max=72"`
 }
 
 // CreateToken godoc
 //
 //	@Summary		Create token
-//	@Description	Create a new token
+//	@Description	Create a new access token and refresh token. Accounts with 2FA enabled receive an MFA challenge token instead, to be exchanged at /authentication/mfa/verify.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body	CreateTokenPayload	true	"Create token payload"
-//	@Success		200		"Token created"
+//	@Param			payload	body		CreateTokenPayload	true	"Create token payload"
+//	@Success		200		{object}	authTokensResponse
+//	@Success		202		{object}	mfaChallengeResponse
 //	@Failure		400		{object}	errorResponse
 //	@Failure		401		{object}	errorResponse
+//	@Failure		429		{object}	errorResponse
 //	@Failure		500		{object}	errorResponse
 //	@Router			/authentication/token [post]
 func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Request) {
@@ -125,23 +183,188 @@ func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	user, err := app.store.Users.ReadByEmail(r.Context(), payload.Email)
+	ctx := r.Context()
+	ip := clientIP(r)
+
+	retryAfter, err := app.loginRetryAfter(ctx, payload.Email, ip)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			app.unauthorized(w, r, err)
+		app.internalServerError(w, r, err)
+		return
+	}
+	if retryAfter > 0 {
+		app.tooManyRequestsRetryAfter(w, r, retryAfter, fmt.Errorf("login temporarily locked"))
+		return
+	}
+
+	user, err := app.store.Users.ReadByEmail(ctx, payload.Email)
+	if err != nil && err != sql.ErrNoRows {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	var credentialsErr error
+	if user == nil {
+		app.store.Users.CompareDummyPassword(payload.Password)
+		credentialsErr = fmt.Errorf("invalid credentials")
+	} else {
+		credentialsErr = user.Password.Compare(payload.Password)
+	}
+
+	if credentialsErr != nil {
+		if err := app.recordLoginFailure(ctx, payload.Email, ip, user); err != nil {
+			app.internalServerError(w, r, err)
 			return
 		}
+		app.unauthorized(w, r, fmt.Errorf("invalid credentials"))
+		return
+	}
+
+	if err := app.resetLoginFailures(ctx, payload.Email); err != nil {
+		app.logger.Errorw("failed to reset login failures", "error", err)
+	}
+
+	if err := app.store.Users.RehashPassword(ctx, user); err != nil {
+		app.logger.Errorw("failed to store rehashed password", "error", err)
+	}
+
+	app.completeLogin(w, r, user)
+}
+
+type RefreshTokenPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// RefreshToken godoc
+//
+//	@Summary		Refresh token
+//	@Description	Exchange a refresh token for a new access token and a rotated refresh token
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		RefreshTokenPayload	true	"Refresh token payload"
+//	@Success		200		{object}	authTokensResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Router			/authentication/refresh [post]
+func (app *application) refreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	refreshToken := uuid.New().String()
+	next := &store.RefreshToken{
+		Token:     hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(app.config.auth.token.refreshExp),
+	}
+
+	err := app.store.RefreshTokens.Rotate(ctx, hashToken(payload.RefreshToken), next)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound, store.ErrRefreshTokenExpired, store.ErrRefreshTokenRevoked:
+			app.unauthorized(w, r, err)
+		case store.ErrRefreshTokenReused:
+			app.logger.Warnw("refresh token reuse detected, family revoked", "user_id", next.UserID, "family_id", next.FamilyID)
+			app.unauthorized(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.store.Sessions.Touch(ctx, next.FamilyID, clientIP(r)); err != nil {
+		app.logger.Errorw("failed to record session activity", "error", err)
+	}
+
+	accessToken, err := app.generateAccessToken(ctx, next.UserID, next.MFAVerified, next.FamilyID)
+	if err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
-	if err := user.Password.Compare(payload.Password); err != nil {
-		app.unauthorized(w, r, err)
+	tokens := authTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(app.config.auth.token.exp.Seconds()),
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, authTokensResponse{Data: tokens}); err != nil {
+		app.internalServerError(w, r, err)
 		return
 	}
+}
+
+type authTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
+}
+
+// issueTokens starts a new session for the user and pairs the first refresh
+// token of its family with a fresh access token. mfaVerified records whether
+// the login passed a second factor and is carried over on every refresh.
+// Signing in to a deactivated account restores it.
+func (app *application) issueTokens(r *http.Request, user *store.User, mfaVerified bool) (*authTokens, error) {
+	ctx := r.Context()
+
+	if user.DeactivatedAt != nil {
+		if err := app.store.Users.Restore(ctx, user.ID); err != nil {
+			return nil, err
+		}
+		app.invalidateUserCache(ctx, user.ID)
+		user.DeactivatedAt = nil
+	}
+
+	session, err := app.startSession(r, user)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := app.generateAccessToken(ctx, user.ID, mfaVerified, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken := uuid.New().String()
+	err = app.store.RefreshTokens.Create(ctx, &store.RefreshToken{
+		UserID:      user.ID,
+		FamilyID:    session.ID,
+		Token:       hashToken(refreshToken),
+		MFAVerified: mfaVerified,
+		ExpiresAt:   time.Now().Add(app.config.auth.token.refreshExp),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &authTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(app.config.auth.token.exp.Seconds()),
+	}, nil
+}
+
+func (app *application) generateAccessToken(ctx context.Context, userID int64, mfaVerified bool, sessionID string) (string, error) {
+	generation, err := app.getTokenGeneration(ctx, userID)
+	if err != nil {
+		return "", err
+	}
 
 	claims := jwt.MapClaims{
-		"sub": user.ID,
+		"sub": userID,
+		"jti": uuid.New().String(),
+		"gen": generation,
+		"mfa": mfaVerified,
+		"sid": sessionID,
 		"exp": time.Now().Add(app.config.auth.token.exp).Unix(),
 		"nbf": time.Now().Unix(),
 		"iat": time.Now().Unix(),
@@ -149,14 +372,101 @@ func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Reques
 		"aud": app.config.auth.token.aud,
 	}
 
-	token, err := app.authenticator.GenerateToken(claims)
-	if err != nil {
+	return app.authenticator.GenerateToken(claims)
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+type LogoutPayload struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Logout godoc
+//
+//	@Summary		Logout
+//	@Description	Revoke the access token used for this request and, if given, the refresh token family it was issued with
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	LogoutPayload	false	"Logout payload"
+//	@Success		204		"Logged out"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/authentication/logout [post]
+func (app *application) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	var payload LogoutPayload
+	if err := readJSON(w, r, &payload); err != nil && err != io.EOF {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+	claims := app.getTokenClaimsContext(r)
+
+	ctx := r.Context()
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		app.unauthorized(w, r, fmt.Errorf("invalid token"))
+		return
+	}
+
+	if err := app.revokeToken(ctx, claims["jti"].(string), user.ID, expiresAt.Time); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
-	if err := app.jsonResponse(w, http.StatusOK, token); err != nil {
+	if payload.RefreshToken != "" {
+		if err := app.store.RefreshTokens.RevokeByToken(ctx, user.ID, hashToken(payload.RefreshToken)); err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if sessionID, _ := claims["sid"].(string); sessionID != "" {
+		if err := app.revokeSession(ctx, sessionID, user.ID); err != nil && err != store.ErrNotFound {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// LogoutAll godoc
+//
+//	@Summary		Logout from all devices
+//	@Description	Revoke every access and refresh token issued to the current user
+//	@Tags			auth
+//	@Produce		json
+//	@Success		204	"Logged out"
+//	@Failure		401	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/authentication/logout/all [post]
+func (app *application) logoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+
+	if err := app.revokeAllSessions(r.Context(), user.ID); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+const tokenClaimsContextKey = contextKey("tokenClaims")
+
+func (app *application) getTokenClaimsContext(r *http.Request) jwt.MapClaims {
+	claims, _ := r.Context().Value(tokenClaimsContextKey).(jwt.MapClaims)
+	return claims
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/andras-szesztai/social/internal/store"
+)
+
+// BlockUser godoc
+//
+//	@Summary		Block user
+//	@Description	Block a user. Follows in both directions are removed and the user can no longer follow you, see your posts or comment on them
+//	@Tags			users
+//	@Param			id	path	int	true	"User ID"
+//	@Success		204	"Success"
+//	@Failure		400	{object}	errorResponse	"Users cannot block themselves"
+//	@Failure		404	{object}	errorResponse
+//	@Failure		409	{object}	errorResponse	"Already blocked"
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/{id}/block [post]
+func (app *application) blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+	target := app.getTargetUserContext(r)
+
+	if err := app.store.Blocks.Block(r.Context(), user.ID, target.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrSelfBlock):
+			app.badRequest(w, r, err)
+		case errors.Is(err, store.ErrNotFound):
+			app.notFound(w, r)
+		case errors.Is(err, store.ErrAlreadyBlocked):
+			app.conflict(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnblockUser godoc
+//
+//	@Summary		Unblock user
+//	@Description	Unblock a user. Follows removed by the block are not restored
+//	@Tags			users
+//	@Param			id	path	int	true	"User ID"
+//	@Success		204	"Success"
+//	@Failure		400	{object}	errorResponse
+//	@Failure		404	{object}	errorResponse	"The user does not exist or is not blocked"
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/{id}/unblock [post]
+func (app *application) unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+	target := app.getTargetUserContext(r)
+
+	if err := app.store.Blocks.Unblock(r.Context(), user.ID, target.ID); err != nil {
+		if errors.Is(err, store.ErrNotBlocked) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MuteUser godoc
+//
+//	@Summary		Mute user
+//	@Description	Hide a user's posts from your feed. The user is not notified
+//	@Tags			users
+//	@Param			id	path	int	true	"User ID"
+//	@Success		204	"Success"
+//	@Failure		400	{object}	errorResponse	"Users cannot mute themselves"
+//	@Failure		404	{object}	errorResponse
+//	@Failure		409	{object}	errorResponse	"Already muted"
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/{id}/mute [post]
+func (app *application) muteUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+	target := app.getTargetUserContext(r)
+
+	if err := app.store.Blocks.Mute(r.Context(), user.ID, target.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrSelfMute):
+			app.badRequest(w, r, err)
+		case errors.Is(err, store.ErrNotFound):
+			app.notFound(w, r)
+		case errors.Is(err, store.ErrAlreadyMuted):
+			app.conflict(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnmuteUser godoc
+//
+//	@Summary		Unmute user
+//	@Description	Show a muted user's posts in your feed again
+//	@Tags			users
+//	@Param			id	path	int	true	"User ID"
+//	@Success		204	"Success"
+//	@Failure		400	{object}	errorResponse
+//	@Failure		404	{object}	errorResponse	"The user does not exist or is not muted"
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/{id}/unmute [post]
+func (app *application) unmuteUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+	target := app.getTargetUserContext(r)
+
+	if err := app.store.Blocks.Unmute(r.Context(), user.ID, target.ID); err != nil {
+		if errors.Is(err, store.ErrNotMuted) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBlockedUsers godoc
+//
+//	@Summary		Get blocked users
+//	@Description	List the users the current user blocked, most recent first
+//	@Tags			users
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit"		default(20)
+//	@Param			offset	query		int	false	"Offset"	default(0)
+//	@Success		200		{object}	relatedUsersResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/blocks [get]
+func (app *application) getBlockedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := app.readPagination(w, r)
+	if !ok {
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	blocked, err := app.store.Blocks.ReadBlocked(r.Context(), user.ID, page)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, relatedUsersResponse{Data: blocked}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetMutedUsers godoc
+//
+//	@Summary		Get muted users
+//	@Description	List the users the current user muted, most recent first
+//	@Tags			users
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit"		default(20)
+//	@Param			offset	query		int	false	"Offset"	default(0)
+//	@Success		200		{object}	relatedUsersResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/mutes [get]
+func (app *application) getMutedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := app.readPagination(w, r)
+	if !ok {
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	muted, err := app.store.Blocks.ReadMuted(r.Context(), user.ID, page)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, relatedUsersResponse{Data: muted}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
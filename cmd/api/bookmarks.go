@@ -0,0 +1,320 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type AddBookmarkPayload struct {
+	PostID   int64  `json:"post_id" validate:"required,gt=0" example:"1"`
+	FolderID *int64 `json:"folder_id" validate:"omitempty,gt=0" example:"1"`
+}
+
+type MoveBookmarkPayload struct {
+	// FolderID is the folder to move the bookmark to, or null to take it
+	// out of its folder.
+	FolderID *int64 `json:"folder_id" validate:"omitempty,gt=0" example:"1"`
+}
+
+type CreateBookmarkFolderPayload struct {
+	Name string `json:"name" validate:"required,max=50" example:"Read later"`
+}
+
+// AddBookmark godoc
+//
+//	@Summary		Add bookmark
+//	@Description	Save a post to read later, optionally into one of your folders
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Param			payload	body	AddBookmarkPayload	true	"Bookmark"
+//	@Success		204		"Success"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse	"The post or folder does not exist"
+//	@Failure		409		{object}	errorResponse	"Already bookmarked"
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks [post]
+func (app *application) addBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	var payload AddBookmarkPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	post, err := app.store.Posts.Read(ctx, payload.PostID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if !app.canViewPost(w, r, post) {
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	if err := app.store.Bookmarks.Add(ctx, user.ID, post.ID, payload.FolderID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFound(w, r)
+		case errors.Is(err, store.ErrAlreadyBookmarked):
+			app.conflict(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MoveBookmark godoc
+//
+//	@Summary		Move bookmark
+//	@Description	Move a bookmark into another folder, or out of its folder
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Param			postID	path	int					true	"Post ID"
+//	@Param			payload	body	MoveBookmarkPayload	true	"Target folder"
+//	@Success		204		"Success"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse	"The bookmark or folder does not exist"
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks/{postID} [patch]
+func (app *application) moveBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.ParseInt(chi.URLParam(r, "postID"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	var payload MoveBookmarkPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	if err := app.store.Bookmarks.Move(r.Context(), user.ID, postID, payload.FolderID); err != nil {
+		if errors.Is(err, store.ErrNotBookmarked) || errors.Is(err, store.ErrNotFound) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveBookmark godoc
+//
+//	@Summary		Remove bookmark
+//	@Description	Remove a post from your bookmarks
+//	@Tags			bookmarks
+//	@Param			postID	path	int	true	"Post ID"
+//	@Success		204		"Success"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse	"The post is not bookmarked"
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks/{postID} [delete]
+func (app *application) removeBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.ParseInt(chi.URLParam(r, "postID"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	if err := app.store.Bookmarks.Remove(r.Context(), user.ID, postID); err != nil {
+		if errors.Is(err, store.ErrNotBookmarked) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBookmarks godoc
+//
+//	@Summary		Get bookmarks
+//	@Description	List your bookmarks, most recently saved first. Posts you can no longer see are left out
+//	@Tags			bookmarks
+//	@Produce		json
+//	@Param			folder_id	query		int		false	"Only list bookmarks in this folder"
+//	@Param			unfiled		query		bool	false	"Only list bookmarks outside any folder"
+//	@Param			limit		query		int		false	"Limit"		default(20)
+//	@Param			offset		query		int		false	"Offset"	default(0)
+//	@Success		200			{object}	bookmarksResponse
+//	@Failure		400			{object}	errorResponse
+//	@Failure		500			{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks [get]
+func (app *application) getBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := app.readPagination(w, r)
+	if !ok {
+		return
+	}
+
+	var filter store.BookmarkFilter
+	query := r.URL.Query()
+	if folderID := query.Get("folder_id"); folderID != "" {
+		id, err := strconv.ParseInt(folderID, 10, 64)
+		if err != nil {
+			app.badRequest(w, r, err)
+			return
+		}
+		filter.FolderID = &id
+	}
+	if unfiled := query.Get("unfiled"); unfiled != "" {
+		b, err := strconv.ParseBool(unfiled)
+		if err != nil {
+			app.badRequest(w, r, err)
+			return
+		}
+		filter.Unfiled = b
+	}
+	if filter.FolderID != nil && filter.Unfiled {
+		app.badRequest(w, r, errors.New("folder_id and unfiled cannot be combined"))
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	bookmarks, err := app.store.Bookmarks.ReadByUserID(r.Context(), user.ID, filter, page)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, bookmarksResponse{Data: bookmarks}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// CreateBookmarkFolder godoc
+//
+//	@Summary		Create bookmark folder
+//	@Description	Create a named folder to sort bookmarks into
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateBookmarkFolderPayload	true	"Folder"
+//	@Success		201		{object}	bookmarkFolderResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		409		{object}	errorResponse	"A folder with this name already exists"
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks/folders [post]
+func (app *application) createBookmarkFolderHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateBookmarkFolderPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	folder, err := app.store.Bookmarks.CreateFolder(r.Context(), user.ID, payload.Name)
+	if err != nil {
+		if errors.Is(err, store.ErrBookmarkFolderExists) {
+			app.conflict(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, bookmarkFolderResponse{Data: *folder}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetBookmarkFolders godoc
+//
+//	@Summary		Get bookmark folders
+//	@Description	List your bookmark folders by name with how many bookmarks each holds
+//	@Tags			bookmarks
+//	@Produce		json
+//	@Success		200	{object}	bookmarkFoldersResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks/folders [get]
+func (app *application) getBookmarkFoldersHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+
+	folders, err := app.store.Bookmarks.ReadFolders(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, bookmarkFoldersResponse{Data: folders}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// DeleteBookmarkFolder godoc
+//
+//	@Summary		Delete bookmark folder
+//	@Description	Delete a bookmark folder. Its bookmarks are kept outside any folder
+//	@Tags			bookmarks
+//	@Param			folderID	path	int	true	"Folder ID"
+//	@Success		204			"Success"
+//	@Failure		400			{object}	errorResponse
+//	@Failure		404			{object}	errorResponse
+//	@Failure		500			{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks/folders/{folderID} [delete]
+func (app *application) deleteBookmarkFolderHandler(w http.ResponseWriter, r *http.Request) {
+	folderID, err := strconv.ParseInt(chi.URLParam(r, "folderID"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	if err := app.store.Bookmarks.DeleteFolder(r.Context(), user.ID, folderID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -210,6 +210,15 @@ func (app *application) commentsContextMiddleware(next http.Handler) http.Handle
 			return
 		}
 
+		post, err := app.store.Posts.Read(r.Context(), comment.PostID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !app.canViewPost(w, r, post) {
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), commentContextKey, comment)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
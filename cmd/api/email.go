@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andras-szesztai/social/internal/mailer"
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/google/uuid"
+)
+
+type ChangeEmailPayload struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+// ChangeEmail godoc
+//
+//	@Summary		Change email
+//	@Description	Start changing the current user's email. A confirmation link is sent to the new address; the email only changes once it is confirmed.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	ChangeEmailPayload	true	"Change email payload"
+//	@Success		202		"Confirmation link sent"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		409		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/email [patch]
+func (app *application) changeEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ChangeEmailPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	// The cached user does not carry the password hash.
+	user, err := app.store.Users.ReadByID(ctx, app.getUserContext(r).ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := user.Password.Compare(payload.Password); err != nil {
+		app.unauthorized(w, r, fmt.Errorf("invalid credentials"))
+		return
+	}
+
+	token := uuid.New().String()
+	change := &store.EmailChange{UserID: user.ID, OldEmail: user.Email, NewEmail: payload.Email}
+
+	err = app.store.EmailChanges.Create(ctx, change, hashToken(token), app.config.mail.emailChangeExpiry)
+	if err != nil {
+		if err == store.ErrEmailAlreadyExists {
+			app.conflict(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	err = app.mailer.Send(mailer.EmailChangeTemplate, user.Username, change.NewEmail, map[string]any{
+		"Username":   user.Username,
+		"ConfirmURL": fmt.Sprintf("%s/confirm-email/%s", app.config.frontendURL, token),
+		"Expiry":     app.config.mail.emailChangeExpiry.String(),
+	}, app.config.env == "production")
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusAccepted, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type EmailChangeTokenPayload struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ConfirmEmailChange godoc
+//
+//	@Summary		Confirm email change
+//	@Description	Switch the account to the new email. The previous address is notified with a link to undo the change.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	EmailChangeTokenPayload	true	"Email change token payload"
+//	@Success		204		"Email changed"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		409		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Router			/authentication/email/confirm [post]
+func (app *application) confirmEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var payload EmailChangeTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	revertToken := uuid.New().String()
+
+	change, err := app.store.EmailChanges.Confirm(ctx, hashToken(payload.Token), hashToken(revertToken), app.config.mail.emailRevertWindow)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFound(w, r)
+		case store.ErrEmailChangeExpired:
+			app.badRequest(w, r, err)
+		case store.ErrEmailAlreadyExists:
+			app.conflict(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidateUserCache(ctx, change.UserID)
+
+	user, err := app.store.Users.ReadByID(ctx, change.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	err = app.mailer.Send(mailer.EmailChangedTemplate, user.Username, change.OldEmail, map[string]any{
+		"Username":  user.Username,
+		"NewEmail":  change.NewEmail,
+		"RevertURL": fmt.Sprintf("%s/revert-email/%s", app.config.frontendURL, revertToken),
+		"Expiry":    app.config.mail.emailRevertWindow.String(),
+	}, app.config.env == "production")
+	if err != nil {
+		app.logger.Errorw("failed to send email changed notification", "error", err)
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// RevertEmailChange godoc
+//
+//	@Summary		Revert email change
+//	@Description	Restore the previous email of the account and sign out every session, in case the change was not made by the owner
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	EmailChangeTokenPayload	true	"Email change token payload"
+//	@Success		204		"Email change reverted"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		409		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Router			/authentication/email/revert [post]
+func (app *application) revertEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var payload EmailChangeTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	change, err := app.store.EmailChanges.Revert(ctx, hashToken(payload.Token))
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFound(w, r)
+		case store.ErrEmailChangeExpired:
+			app.badRequest(w, r, err)
+		case store.ErrEmailAlreadyExists:
+			app.conflict(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidateUserCache(ctx, change.UserID)
+
+	if err := app.revokeAllSessions(ctx, change.UserID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
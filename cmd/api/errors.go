@@ -1,7 +1,10 @@
 package main
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func (app *application) internalServerError(w http.ResponseWriter, r *http.Request, err error) {
@@ -28,6 +31,14 @@ func (app *application) notFound(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (app *application) conflict(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Warnw("conflict", "method", r.Method, "url", r.URL.Path, "error", err.Error())
+	err = writeJSONError(w, http.StatusConflict, err.Error())
+	if err != nil {
+		app.logger.Errorw("failed to write JSON error", "error", err.Error())
+	}
+}
+
 func (app *application) unauthorized(w http.ResponseWriter, r *http.Request, err error) {
 	app.logger.Warnw("unauthorized", "method", r.Method, "url", r.URL.Path, "error", err.Error())
 	err = writeJSONError(w, http.StatusUnauthorized, "unauthorized")
@@ -62,3 +73,24 @@ func (app *application) tooManyRequests(w http.ResponseWriter, r *http.Request,
 		app.logger.Errorw("failed to write JSON error", "error", err.Error())
 	}
 }
+
+func (app *application) tooManyRequestsRetryAfter(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, err error) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	app.tooManyRequests(w, r, err)
+}
+
+func (app *application) payloadTooLarge(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Warnw("payload too large", "method", r.Method, "url", r.URL.Path, "error", err.Error())
+	err = writeJSONError(w, http.StatusRequestEntityTooLarge, err.Error())
+	if err != nil {
+		app.logger.Errorw("failed to write JSON error", "error", err.Error())
+	}
+}
+
+func (app *application) unsupportedMediaType(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Warnw("unsupported media type", "method", r.Method, "url", r.URL.Path, "error", err.Error())
+	err = writeJSONError(w, http.StatusUnsupportedMediaType, err.Error())
+	if err != nil {
+		app.logger.Errorw("failed to write JSON error", "error", err.Error())
+	}
+}
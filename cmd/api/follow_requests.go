@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetFollowRequests godoc
+//
+//	@Summary		Get follow requests
+//	@Description	List the pending requests to follow the current user, oldest first
+//	@Tags			users
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit"		default(20)
+//	@Param			offset	query		int	false	"Offset"	default(0)
+//	@Success		200		{object}	relatedUsersResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/follow-requests [get]
+func (app *application) getFollowRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := app.readPagination(w, r)
+	if !ok {
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	requesters, err := app.store.FollowRequests.ReadByUserID(r.Context(), user.ID, page)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, relatedUsersResponse{Data: requesters}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// ApproveFollowRequest godoc
+//
+//	@Summary		Approve follow request
+//	@Description	Approve a pending follow request, making the requester a follower
+//	@Tags			users
+//	@Param			requesterID	path	int	true	"Requester user ID"
+//	@Success		204			"Success"
+//	@Failure		400			{object}	errorResponse
+//	@Failure		404			{object}	errorResponse
+//	@Failure		500			{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/follow-requests/{requesterID}/approve [post]
+func (app *application) approveFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	app.resolveFollowRequest(w, r, app.store.FollowRequests.Approve)
+}
+
+// RejectFollowRequest godoc
+//
+//	@Summary		Reject follow request
+//	@Description	Reject a pending follow request. The requester is not notified
+//	@Tags			users
+//	@Param			requesterID	path	int	true	"Requester user ID"
+//	@Success		204			"Success"
+//	@Failure		400			{object}	errorResponse
+//	@Failure		404			{object}	errorResponse
+//	@Failure		500			{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/follow-requests/{requesterID}/reject [post]
+func (app *application) rejectFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	app.resolveFollowRequest(w, r, app.store.FollowRequests.Reject)
+}
+
+func (app *application) resolveFollowRequest(w http.ResponseWriter, r *http.Request, resolve func(ctx context.Context, userID, requesterID int64) error) {
+	requesterID, err := strconv.ParseInt(chi.URLParam(r, "requesterID"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	if err := resolve(r.Context(), user.ID, requesterID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
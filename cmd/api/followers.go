@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/andras-szesztai/social/internal/utils"
+)
+
+// GetFollowers godoc
+//
+//	@Summary		Get followers
+//	@Description	List the users following a user, most recent first
+//	@Tags			users
+//	@Produce		json
+//	@Param			id		path		int	true	"User ID"
+//	@Param			limit	query		int	false	"Limit"		default(20)
+//	@Param			offset	query		int	false	"Offset"	default(0)
+//	@Success		200		{object}	connectionsResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/{id}/followers [get]
+func (app *application) getFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := app.readPagination(w, r)
+	if !ok {
+		return
+	}
+
+	target := app.getTargetUserContext(r)
+	caller := app.getUserContext(r)
+
+	followers, err := app.store.Users.ReadFollowers(r.Context(), target.ID, caller.ID, page)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, connectionsResponse{Data: followers}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetFollowing godoc
+//
+//	@Summary		Get following
+//	@Description	List the users a user follows, most recent first
+//	@Tags			users
+//	@Produce		json
+//	@Param			id		path		int	true	"User ID"
+//	@Param			limit	query		int	false	"Limit"		default(20)
+//	@Param			offset	query		int	false	"Offset"	default(0)
+//	@Success		200		{object}	connectionsResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/{id}/following [get]
+func (app *application) getFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := app.readPagination(w, r)
+	if !ok {
+		return
+	}
+
+	target := app.getTargetUserContext(r)
+	caller := app.getUserContext(r)
+
+	following, err := app.store.Users.ReadFollowing(r.Context(), target.ID, caller.ID, page)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, connectionsResponse{Data: following}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// readPagination parses and validates limit and offset, writing a bad
+// request response when they are invalid.
+func (app *application) readPagination(w http.ResponseWriter, r *http.Request) (utils.PaginationQuery, bool) {
+	page, err := utils.PaginationQuery{Limit: 20}.Parse(r)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return page, false
+	}
+
+	if err := Validator.Struct(page); err != nil {
+		app.badRequest(w, r, err)
+		return page, false
+	}
+
+	return page, true
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// runPeriodically calls job every interval until ctx is cancelled. Failures
+// are logged and retried on the next tick.
+func (app *application) runPeriodically(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				app.logger.Errorw("background job failed", "job", name, "error", err)
+			}
+		}
+	}
+}
+
+// purgeUnactivatedUsers removes expired invitations and accounts that were
+// never activated within the grace period.
+func (app *application) purgeUnactivatedUsers(ctx context.Context) error {
+	invitations, users, err := app.store.Users.PurgeUnactivated(ctx, app.config.jobs.unactivatedGrace)
+	if err != nil {
+		return err
+	}
+
+	if invitations > 0 || users > 0 {
+		app.logger.Infow("purged unactivated users", "invitations", invitations, "users", users)
+	}
+
+	return nil
+}
+
+// purgeDeactivatedUsers applies the purge policy to accounts whose restore
+// window has passed and removes the media blobs nothing refers to anymore.
+func (app *application) purgeDeactivatedUsers(ctx context.Context) error {
+	for {
+		userIDs, keys, err := app.store.Users.PurgeDeactivated(ctx, app.config.jobs.restoreWindow, app.config.jobs.purgePolicy)
+		if err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		for _, key := range keys {
+			if err := app.storage.Delete(ctx, key); err != nil {
+				app.logger.Errorw("failed to delete blob", "key", key, "error", err)
+			}
+		}
+
+		for _, id := range userIDs {
+			app.invalidateUserCache(ctx, id)
+		}
+
+		app.logger.Infow("purged deactivated users", "users", len(userIDs), "policy", app.config.jobs.purgePolicy, "blobs", len(keys))
+	}
+}
+
+// purgeExpiredRevokedTokens drops revocations of access tokens that would
+// be rejected for their expiry anyway.
+func (app *application) purgeExpiredRevokedTokens(ctx context.Context) error {
+	purged, err := app.store.RevokedTokens.PurgeExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		app.logger.Infow("purged expired revoked tokens", "tokens", purged)
+	}
+
+	return nil
+}
+
+// publishBatchSize bounds the posts published per query.
+const publishBatchSize = 100
+
+// publishScheduledPosts publishes the scheduled posts that are due. Replicas
+// running it at the same time skip each other's rows.
+func (app *application) publishScheduledPosts(ctx context.Context) error {
+	for {
+		ids, err := app.store.Posts.PublishDue(ctx, publishBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(ids) > 0 {
+			app.logger.Infow("published scheduled posts", "posts", len(ids))
+		}
+		if len(ids) < publishBatchSize {
+			return nil
+		}
+	}
+}
+
+// startJobs launches the background jobs. They stop when ctx is cancelled.
+func (app *application) startJobs(ctx context.Context) {
+	go app.runPeriodically(ctx, "purge_unactivated_users", app.config.jobs.purgeInterval, app.purgeUnactivatedUsers)
+	go app.runPeriodically(ctx, "purge_deactivated_users", app.config.jobs.purgeInterval, app.purgeDeactivatedUsers)
+	go app.runPeriodically(ctx, "purge_expired_revoked_tokens", app.config.jobs.purgeInterval, app.purgeExpiredRevokedTokens)
+	go app.runPeriodically(ctx, "publish_scheduled_posts", app.config.jobs.publishInterval, app.publishScheduledPosts)
+}
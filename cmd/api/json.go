@@ -46,14 +46,58 @@ type userResponse struct {
 	Data store.User `json:"data"`
 }
 
+type publicUserResponse struct {
+	Data store.PublicUser `json:"data"`
+}
+
+type connectionsResponse struct {
+	Data []store.Connection `json:"data"`
+}
+
+type relatedUsersResponse struct {
+	Data []store.RelatedUser `json:"data"`
+}
+
 type userFeedResponse struct {
 	Data []store.UserFeed `json:"data"`
 }
 
+type mediaResponse struct {
+	Data store.Media `json:"data"`
+}
+
 type postResponse struct {
 	Data store.Post `json:"data"`
 }
 
+type reactionsResponse struct {
+	Data []store.Reaction `json:"data"`
+}
+
+type bookmarksResponse struct {
+	Data []store.Bookmark `json:"data"`
+}
+
+type bookmarkFolderResponse struct {
+	Data store.BookmarkFolder `json:"data"`
+}
+
+type bookmarkFoldersResponse struct {
+	Data []store.BookmarkFolder `json:"data"`
+}
+
+type postsResponse struct {
+	Data []store.Post `json:"data"`
+}
+
+type postRevisionResponse struct {
+	Data store.PostRevision `json:"data"`
+}
+
+type postRevisionsResponse struct {
+	Data []store.PostRevision `json:"data"`
+}
+
 type commentResponse struct {
 	Data store.Comment `json:"data"`
 }
@@ -61,3 +105,47 @@ type commentResponse struct {
 type commentsResponse struct {
 	Data []store.Comment `json:"data"`
 }
+
+type authTokensResponse struct {
+	Data authTokens `json:"data"`
+}
+
+type mfaChallengeResponse struct {
+	Data mfaChallenge `json:"data"`
+}
+
+type totpEnrollmentResponse struct {
+	Data totpEnrollment `json:"data"`
+}
+
+type recoveryCodesResponse struct {
+	Data recoveryCodes `json:"data"`
+}
+
+type createdPersonalAccessTokenResponse struct {
+	Data createdPersonalAccessToken `json:"data"`
+}
+
+type personalAccessTokensResponse struct {
+	Data []store.PersonalAccessToken `json:"data"`
+}
+
+type lockoutsResponse struct {
+	Data []store.LoginFailure `json:"data"`
+}
+
+type oidcAuthorizationResponse struct {
+	Data oidcAuthorization `json:"data"`
+}
+
+type identityResponse struct {
+	Data store.Identity `json:"data"`
+}
+
+type identitiesResponse struct {
+	Data []store.Identity `json:"data"`
+}
+
+type sessionsResponse struct {
+	Data []store.Session `json:"data"`
+}
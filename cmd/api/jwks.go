@@ -0,0 +1,15 @@
+package main
+
+import (
+	"net/http"
+)
+
+// jwksHandler publishes the public signing keys as a JSON Web Key Set so
+// other services can verify our tokens without sharing a secret. It is served
+// from the well-known location outside of the versioned API.
+func (app *application) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := app.jsonResponse(w, http.StatusOK, app.authenticator.JWKS()); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/mailer"
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type lockoutConfig struct {
+	enabled         bool
+	maxFailures     int
+	ipMaxFailures   int
+	baseDelay       time.Duration
+	lockoutDuration time.Duration
+	maxLockout      time.Duration
+	resetAfter      time.Duration
+}
+
+// loginRetryAfter returns how long the caller has to wait before another
+// login attempt for the email from the ip is allowed.
+func (app *application) loginRetryAfter(ctx context.Context, email, ip string) (time.Duration, error) {
+	if !app.config.auth.lockout.enabled {
+		return 0, nil
+	}
+
+	var retryAfter time.Duration
+	for scope, subject := range map[string]string{
+		store.LoginFailureScopeAccount: normalizeEmail(email),
+		store.LoginFailureScopeIP:      ip,
+	} {
+		failure, err := app.store.LoginFailures.Read(ctx, scope, subject)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return 0, err
+		}
+
+		if failure.LockedUntil != nil {
+			retryAfter = max(retryAfter, time.Until(*failure.LockedUntil))
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// recordLoginFailure counts a failed attempt against both the account and
+// the ip. Every failure delays the next attempt exponentially; once a
+// counter reaches its limit the subject is locked out, and the account owner
+// is notified by email the first time that happens.
+func (app *application) recordLoginFailure(ctx context.Context, email, ip string, user *store.User) error {
+	if !app.config.auth.lockout.enabled {
+		return nil
+	}
+
+	cfg := app.config.auth.lockout
+
+	account, err := app.store.LoginFailures.RecordFailure(ctx, store.LoginFailureScopeAccount, normalizeEmail(email), cfg.resetAfter)
+	if err != nil {
+		return err
+	}
+
+	lockedUntil := time.Now().Add(loginBackoff(account.Failures, cfg.maxFailures, cfg))
+	if err := app.store.LoginFailures.Lock(ctx, account.ID, lockedUntil); err != nil {
+		return err
+	}
+
+	if account.Failures == cfg.maxFailures && user != nil {
+		err := app.mailer.Send(mailer.AccountLockedTemplate, user.Username, user.Email, map[string]any{
+			"Username":    user.Username,
+			"LockedUntil": lockedUntil.UTC().Format(time.RFC1123),
+			"ResetURL":    fmt.Sprintf("%s/forgot-password", app.config.frontendURL),
+		}, app.config.env == "production")
+		if err != nil {
+			app.logger.Errorw("failed to send account locked email", "error", err)
+		}
+	}
+
+	ipFailure, err := app.store.LoginFailures.RecordFailure(ctx, store.LoginFailureScopeIP, ip, cfg.resetAfter)
+	if err != nil {
+		return err
+	}
+
+	return app.store.LoginFailures.Lock(ctx, ipFailure.ID, time.Now().Add(loginBackoff(ipFailure.Failures, cfg.ipMaxFailures, cfg)))
+}
+
+func (app *application) resetLoginFailures(ctx context.Context, email string) error {
+	if !app.config.auth.lockout.enabled {
+		return nil
+	}
+
+	return app.store.LoginFailures.Reset(ctx, store.LoginFailureScopeAccount, normalizeEmail(email))
+}
+
+// loginBackoff doubles the delay with every failure: from baseDelay (capped
+// at lockoutDuration) while below the limit, and from lockoutDuration (capped
+// at maxLockout) once the limit is reached.
+func loginBackoff(failures, limit int, cfg lockoutConfig) time.Duration {
+	if failures < limit {
+		delay := float64(cfg.baseDelay) * math.Pow(2, float64(failures-1))
+		return time.Duration(math.Min(delay, float64(cfg.lockoutDuration)))
+	}
+
+	lockout := float64(cfg.lockoutDuration) * math.Pow(2, float64(failures-limit))
+	return time.Duration(math.Min(lockout, float64(cfg.maxLockout)))
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// GetLockouts godoc
+//
+//	@Summary		List lockouts
+//	@Description	List accounts and IPs that are currently locked out of logging in
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	lockoutsResponse
+//	@Failure		401	{object}	errorResponse
+//	@Failure		403	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/admin/lockouts [get]
+func (app *application) getLockoutsHandler(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := app.store.LoginFailures.ReadLocked(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, lockoutsResponse{Data: lockouts}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// ClearLockout godoc
+//
+//	@Summary		Clear lockout
+//	@Description	Clear the failed login counter and lockout of an account or IP
+//	@Tags			admin
+//	@Produce		json
+//	@Param			id	path	int	true	"Lockout ID"
+//	@Success		204	"Lockout cleared"
+//	@Failure		400	{object}	errorResponse
+//	@Failure		401	{object}	errorResponse
+//	@Failure		403	{object}	errorResponse
+//	@Failure		404	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/admin/lockouts/{id} [delete]
+func (app *application) clearLockoutHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	err = app.store.LoginFailures.Delete(r.Context(), id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
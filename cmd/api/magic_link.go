@@ -0,0 +1,143 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/andras-szesztai/social/internal/mailer"
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/google/uuid"
+)
+
+type RequestMagicLinkPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// RequestMagicLink godoc
+//
+//	@Summary		Request magic link
+//	@Description	Email a single-use sign-in link to the account with the given email, if there is one
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	RequestMagicLinkPayload	true	"Request magic link payload"
+//	@Success		202		"Sign-in link sent if the account exists"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Router			/authentication/magic-link [post]
+func (app *application) requestMagicLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.config.auth.magicLink.enabled {
+		app.notFound(w, r)
+		return
+	}
+
+	var payload RequestMagicLinkPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	// The response is the same whether or not the account exists so the
+	// endpoint cannot be used to enumerate registered emails.
+	user, err := app.store.Users.ReadByEmail(ctx, payload.Email)
+	if err != nil && err != sql.ErrNoRows {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if user != nil {
+		token := uuid.New().String()
+
+		err := app.store.MagicLinks.Create(ctx, user.ID, hashToken(token), app.config.auth.magicLink.exp)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		err = app.mailer.Send(mailer.MagicLinkTemplate, user.Username, user.Email, map[string]any{
+			"Username": user.Username,
+			"LoginURL": fmt.Sprintf("%s/magic-link/%s", app.config.frontendURL, token),
+			"Expiry":   app.config.auth.magicLink.exp.String(),
+		}, app.config.env == "production")
+		if err != nil {
+			app.logger.Errorw("failed to send magic link email", "error", err)
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusAccepted, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type RedeemMagicLinkPayload struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RedeemMagicLink godoc
+//
+//	@Summary		Redeem magic link
+//	@Description	Exchange a sign-in link token for an access token and refresh token. Accounts with 2FA enabled receive an MFA challenge token instead.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		RedeemMagicLinkPayload	true	"Redeem magic link payload"
+//	@Success		200		{object}	authTokensResponse
+//	@Success		202		{object}	mfaChallengeResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Router			/authentication/magic-link/redeem [post]
+func (app *application) redeemMagicLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.config.auth.magicLink.enabled {
+		app.notFound(w, r)
+		return
+	}
+
+	var payload RedeemMagicLinkPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, err := app.store.MagicLinks.Redeem(ctx, hashToken(payload.Token))
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.unauthorized(w, r, fmt.Errorf("invalid magic link"))
+		case store.ErrMagicLinkExpired:
+			app.unauthorized(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user, err := app.store.Users.ReadByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.unauthorized(w, r, fmt.Errorf("invalid magic link"))
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.completeLogin(w, r, user)
+}
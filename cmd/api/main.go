@@ -1,18 +1,23 @@
 package main
 
 import (
+	"context"
 	"expvar"
+	"fmt"
 	"time"
 
 	"github.com/andras-szesztai/social/internal/auth"
 	"github.com/andras-szesztai/social/internal/db"
 	"github.com/andras-szesztai/social/internal/env"
 	"github.com/andras-szesztai/social/internal/mailer"
+	"github.com/andras-szesztai/social/internal/oidc"
 	"github.com/andras-szesztai/social/internal/ratelimiter"
+	"github.com/andras-szesztai/social/internal/storage"
 	"github.com/andras-szesztai/social/internal/store"
 	"github.com/andras-szesztai/social/internal/store/cache"
 	_ "github.com/swaggo/http-swagger/v2"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const version = "0.0.1"
@@ -43,9 +48,13 @@ func main() {
 			maxIdleTime:  env.GetString("DB_MAX_IDLE_TIME", "15m"),
 		},
 		mail: mailConfig{
-			expiry: 24 * time.Hour,
-			apiKey: env.GetString("MAIL_API_KEY", ""),
-			from:   env.GetString("MAIL_FROM", ""),
+			expiry:            env.GetDuration("INVITATION_EXP", 24*time.Hour),
+			resendCooldown:    env.GetDuration("INVITATION_RESEND_COOLDOWN", time.Minute),
+			resetExpiry:       env.GetDuration("PASSWORD_RESET_EXP", time.Hour),
+			emailChangeExpiry: env.GetDuration("EMAIL_CHANGE_EXP", 24*time.Hour),
+			emailRevertWindow: env.GetDuration("EMAIL_CHANGE_REVERT_WINDOW", 7*24*time.Hour),
+			apiKey:            env.GetString("MAIL_API_KEY", ""),
+			from:              env.GetString("MAIL_FROM", ""),
 		},
 		auth: authConfig{
 			basic: basicAuthConfig{
@@ -53,10 +62,45 @@ func main() {
 				password: env.GetString("BASIC_AUTH_PASSWORD", "admin"),
 			},
 			token: tokenConfig{
-				secret: env.GetString("TOKEN_SECRET", ""),
-				exp:    env.GetDuration("TOKEN_EXP", 3*24*time.Hour),
-				aud:    env.GetString("TOKEN_AUD", ""),
-				iss:    env.GetString("TOKEN_ISS", ""),
+				secret:     env.GetString("TOKEN_SECRET", ""),
+				keysFile:   env.GetString("TOKEN_KEYS_FILE", ""),
+				keyGrace:   env.GetDuration("TOKEN_KEY_GRACE", 24*time.Hour),
+				exp:        env.GetDuration("TOKEN_EXP", 15*time.Minute),
+				refreshExp: env.GetDuration("REFRESH_TOKEN_EXP", 7*24*time.Hour),
+				aud:        env.GetString("TOKEN_AUD", ""),
+				iss:        env.GetString("TOKEN_ISS", ""),
+			},
+			mfa: mfaConfig{
+				issuer:       env.GetString("MFA_ISSUER", "Social"),
+				challengeExp: env.GetDuration("MFA_CHALLENGE_EXP", 5*time.Minute),
+				requiredRole: env.GetString("MFA_REQUIRED_ROLE", ""),
+				maxAttempts:  env.GetInt("MFA_MAX_ATTEMPTS", 5),
+			},
+			lockout: lockoutConfig{
+				enabled:         env.GetBool("LOGIN_LOCKOUT_ENABLED", true),
+				maxFailures:     env.GetInt("LOGIN_LOCKOUT_MAX_FAILURES", 5),
+				ipMaxFailures:   env.GetInt("LOGIN_LOCKOUT_IP_MAX_FAILURES", 20),
+				baseDelay:       env.GetDuration("LOGIN_LOCKOUT_BASE_DELAY", time.Second),
+				lockoutDuration: env.GetDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+				maxLockout:      env.GetDuration("LOGIN_LOCKOUT_MAX_DURATION", 24*time.Hour),
+				resetAfter:      env.GetDuration("LOGIN_LOCKOUT_RESET_AFTER", 24*time.Hour),
+			},
+			magicLink: magicLinkConfig{
+				enabled: env.GetBool("MAGIC_LINK_ENABLED", false),
+				exp:     env.GetDuration("MAGIC_LINK_EXP", 15*time.Minute),
+			},
+			oidc: oidcConfig{
+				providersFile: env.GetString("OIDC_PROVIDERS_FILE", ""),
+				requestExp:    env.GetDuration("OIDC_REQUEST_EXP", 10*time.Minute),
+			},
+			password: passwordConfig{
+				minLength:         env.GetInt("PASSWORD_MIN_LENGTH", 8),
+				blocklistFile:     env.GetString("PASSWORD_BLOCKLIST_FILE", ""),
+				hasher:            env.GetString("PASSWORD_HASHER", "bcrypt"),
+				bcryptCost:        env.GetInt("PASSWORD_BCRYPT_COST", bcrypt.DefaultCost),
+				argon2Memory:      env.GetInt("PASSWORD_ARGON2_MEMORY", 64*1024),
+				argon2Iterations:  env.GetInt("PASSWORD_ARGON2_ITERATIONS", 3),
+				argon2Parallelism: env.GetInt("PASSWORD_ARGON2_PARALLELISM", 2),
 			},
 		},
 		redis: redisConfig{
@@ -70,6 +114,32 @@ func main() {
 			RequestPerTimeFrame: env.GetInt("RATE_LIMITER_REQUEST_PER_TIME_FRAME", 100),
 			TimeFrame:           env.GetDuration("RATE_LIMITER_TIME_FRAME", 1*time.Minute),
 		},
+		storage: storageConfig{
+			backend:       env.GetString("STORAGE_BACKEND", "local"),
+			localDir:      env.GetString("STORAGE_LOCAL_DIR", "./uploads"),
+			signingSecret: env.GetString("STORAGE_SIGNING_SECRET", ""),
+			s3: storage.S3Config{
+				Endpoint:        env.GetString("STORAGE_S3_ENDPOINT", "http://localhost:9000"),
+				Region:          env.GetString("STORAGE_S3_REGION", "us-east-1"),
+				Bucket:          env.GetString("STORAGE_S3_BUCKET", "social"),
+				AccessKeyID:     env.GetString("STORAGE_S3_ACCESS_KEY_ID", ""),
+				SecretAccessKey: env.GetString("STORAGE_S3_SECRET_ACCESS_KEY", ""),
+				PathStyle:       env.GetBool("STORAGE_S3_PATH_STYLE", true),
+			},
+		},
+		media: mediaConfig{
+			baseURL:       env.GetString("MEDIA_BASE_URL", "http://localhost:8080/v1/media"),
+			maxUploadSize: int64(env.GetInt("MEDIA_MAX_UPLOAD_SIZE", 10<<20)),
+			maxPixels:     env.GetInt("MEDIA_MAX_PIXELS", 50_000_000),
+			urlExpiry:     env.GetDuration("MEDIA_URL_EXP", 15*time.Minute),
+		},
+		jobs: jobsConfig{
+			purgeInterval:    env.GetDuration("JOBS_PURGE_INTERVAL", time.Hour),
+			publishInterval:  env.GetDuration("JOBS_PUBLISH_INTERVAL", time.Minute),
+			unactivatedGrace: env.GetDuration("UNACTIVATED_USER_GRACE", 7*24*time.Hour),
+			restoreWindow:    env.GetDuration("ACCOUNT_RESTORE_WINDOW", 30*24*time.Hour),
+			purgePolicy:      store.PurgePolicy(env.GetString("ACCOUNT_PURGE_POLICY", string(store.PurgeAnonymize))),
+		},
 	}
 
 	logger := zap.Must(zap.NewProduction()).Sugar()
@@ -99,19 +169,81 @@ func main() {
 		redisCache = nil
 	}
 
-	store := store.NewStore(db)
+	var passwordHasher store.PasswordHasher
+	switch cfg.auth.password.hasher {
+	case "bcrypt":
+		passwordHasher = store.BcryptHasher{Cost: cfg.auth.password.bcryptCost}
+	case "argon2id":
+		passwordHasher = store.Argon2idHasher{
+			Memory:      uint32(cfg.auth.password.argon2Memory),
+			Iterations:  uint32(cfg.auth.password.argon2Iterations),
+			Parallelism: uint8(cfg.auth.password.argon2Parallelism),
+			SaltLength:  16,
+			KeyLength:   32,
+		}
+	default:
+		logger.Fatalf("unknown password hasher %q", cfg.auth.password.hasher)
+	}
+
+	switch cfg.jobs.purgePolicy {
+	case store.PurgeAnonymize, store.PurgeCascade:
+	default:
+		logger.Fatalf("unknown account purge policy %q", cfg.jobs.purgePolicy)
+	}
+
+	store := store.NewStore(db, passwordHasher)
 
 	mailer := mailer.NewSendGridMailer(cfg.mail.from, cfg.mail.apiKey)
 
-	authenticator := auth.NewJWTAuthenticator(cfg.auth.token.secret, cfg.auth.token.aud, cfg.auth.token.iss)
+	keys := auth.NewHMACKeySet(cfg.auth.token.secret)
+	if cfg.auth.token.keysFile != "" {
+		keys, err = auth.LoadKeySet(cfg.auth.token.keysFile, cfg.auth.token.keyGrace)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	passwordPolicy, err := auth.NewPasswordPolicy(cfg.auth.password.minLength, cfg.auth.password.blocklistFile)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	oidcProviders := map[string]oidc.Provider{}
+	if cfg.auth.oidc.providersFile != "" {
+		oidcProviders, err = oidc.LoadProviders(cfg.auth.oidc.providersFile, nil)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	var blobStorage storage.Storage
+	switch cfg.storage.backend {
+	case "local":
+		if cfg.storage.signingSecret == "" {
+			logger.Fatal("STORAGE_SIGNING_SECRET is required for the local storage backend")
+		}
+		blobStorage, err = storage.NewLocalStorage(cfg.storage.localDir, cfg.media.baseURL+"/files", cfg.storage.signingSecret)
+	case "s3":
+		blobStorage, err = storage.NewS3Storage(cfg.storage.s3, nil)
+	default:
+		err = fmt.Errorf("unknown storage backend %q", cfg.storage.backend)
+	}
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	authenticator := auth.NewJWTAuthenticator(keys, cfg.auth.token.aud, cfg.auth.token.iss)
 
 	app := application{
-		config:        cfg,
-		store:         store,
-		cache:         cache.NewRedisStorage(redisCache),
-		logger:        logger,
-		mailer:        mailer,
-		authenticator: authenticator,
+		config:         cfg,
+		store:          store,
+		cache:          cache.NewRedisStorage(redisCache),
+		logger:         logger,
+		mailer:         mailer,
+		authenticator:  authenticator,
+		oidcProviders:  oidcProviders,
+		passwordPolicy: passwordPolicy,
+		storage:        blobStorage,
 		rateLimiter: ratelimiter.NewFixedWindowLimiter(
 			cfg.rateLimiter.RequestPerTimeFrame,
 			cfg.rateLimiter.TimeFrame,
@@ -123,6 +255,10 @@ func main() {
 		return db.Stats()
 	}))
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	app.startJobs(jobsCtx)
+
 	err = app.serve(app.mountRoutes())
 	if err != nil {
 		logger.Fatal(err)
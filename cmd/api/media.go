@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/andras-szesztai/social/internal/media"
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead leaves room for the multipart framing around the file.
+const multipartOverhead = 64 * 1024
+
+var (
+	errUploadTooLarge = errors.New("file is too large")
+	errInvalidUpload  = errors.New("expected a multipart/form-data request with a file field")
+)
+
+// readUpload reads the "file" part of a multipart/form-data request within
+// the configured size limit.
+func (app *application) readUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	maxSize := app.config.media.maxUploadSize
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, errUploadTooLarge
+		}
+		return nil, fmt.Errorf("%w: %v", errInvalidUpload, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errUploadTooLarge
+	}
+
+	return data, nil
+}
+
+// storeMedia processes the image, writes the original and its thumbnails to
+// the blob storage and records them. Blobs are removed again on failure.
+func (app *application) storeMedia(ctx context.Context, userID int64, data []byte) (*store.Media, error) {
+	img, err := media.Process(data, app.config.media.maxPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := "media/" + uuid.NewString() + "/"
+	m := &store.Media{
+		UserID:      userID,
+		Key:         prefix + "original" + media.Extension(img.ContentType),
+		VariantKeys: []string{},
+		ContentType: img.ContentType,
+		Size:        int64(len(img.Data)),
+		Width:       img.Width,
+		Height:      img.Height,
+	}
+
+	var written []string
+	cleanup := func() {
+		for _, key := range written {
+			if err := app.storage.Delete(context.Background(), key); err != nil {
+				app.logger.Errorw("failed to delete blob", "key", key, "error", err)
+			}
+		}
+	}
+
+	if err := app.storage.Put(ctx, m.Key, bytes.NewReader(img.Data), img.ContentType); err != nil {
+		return nil, err
+	}
+	written = append(written, m.Key)
+
+	for _, variant := range media.Variants {
+		thumb, err := img.Thumbnail(variant)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+
+		key := prefix + variant.Name + media.Extension(thumb.ContentType)
+		if err := app.storage.Put(ctx, key, bytes.NewReader(thumb.Data), thumb.ContentType); err != nil {
+			cleanup()
+			return nil, err
+		}
+		written = append(written, key)
+		m.VariantKeys = append(m.VariantKeys, key)
+	}
+
+	if err := app.store.Media.Create(ctx, m); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// signMedia fills in signed download URLs for the original and variants.
+func (app *application) signMedia(ctx context.Context, m *store.Media) error {
+	m.URLs = make(map[string]string)
+	for name, key := range m.Keys() {
+		url, err := app.storage.SignedURL(ctx, key, app.config.media.urlExpiry)
+		if err != nil {
+			return err
+		}
+		m.URLs[name] = url
+	}
+
+	return nil
+}
+
+// uploadError writes the response for errors from readUpload and storeMedia.
+func (app *application) uploadError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errUploadTooLarge):
+		app.payloadTooLarge(w, r, fmt.Errorf("%w, the limit is %d bytes", err, app.config.media.maxUploadSize))
+	case errors.Is(err, media.ErrUnsupportedType):
+		app.unsupportedMediaType(w, r, err)
+	case errors.Is(err, errInvalidUpload), errors.Is(err, media.ErrInvalidImage), errors.Is(err, media.ErrTooManyPixels):
+		app.badRequest(w, r, err)
+	default:
+		app.internalServerError(w, r, err)
+	}
+}
+
+// UploadMedia godoc
+//
+//	@Summary		Upload media
+//	@Description	Upload a JPEG, PNG or GIF image. Metadata is stripped and thumbnails are generated; the response carries signed, expiring download URLs
+//	@Tags			media
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			file	formData	file	true	"Image file"
+//	@Success		201		{object}	mediaResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		413		{object}	errorResponse
+//	@Failure		415		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/media [post]
+func (app *application) uploadMediaHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := app.readUpload(w, r)
+	if err != nil {
+		app.uploadError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	user := app.getUserContext(r)
+
+	m, err := app.storeMedia(ctx, user.ID, data)
+	if err != nil {
+		app.uploadError(w, r, err)
+		return
+	}
+
+	if err := app.signMedia(ctx, m); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, mediaResponse{Data: *m}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetMedia godoc
+//
+//	@Summary		Get media
+//	@Description	Get one of the current user's uploads with freshly signed download URLs
+//	@Tags			media
+//	@Produce		json
+//	@Param			id	path		int	true	"Media ID"
+//	@Success		200	{object}	mediaResponse
+//	@Failure		400	{object}	errorResponse
+//	@Failure		404	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/media/{id} [get]
+func (app *application) getMediaHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	m, err := app.store.Media.Read(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if m.UserID != app.getUserContext(r).ID {
+		app.notFound(w, r)
+		return
+	}
+
+	if err := app.signMedia(ctx, m); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, mediaResponse{Data: *m}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetMediaContent godoc
+//
+//	@Summary		Download media
+//	@Description	Redirect to a signed download URL for the original or a thumbnail. The URL is stable, which makes it usable as an avatar URL. Only avatars, the caller's own uploads and media of posts the caller can see are served; authentication is optional
+//	@Tags			media
+//	@Param			id		path	int		true	"Media ID"
+//	@Param			variant	query	string	false	"original, small, medium or large"	default(original)
+//	@Success		302
+//	@Failure		400	{object}	errorResponse
+//	@Failure		404	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Router			/media/{id}/content [get]
+func (app *application) getMediaContentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	variant := r.URL.Query().Get("variant")
+	if variant == "" {
+		variant = "original"
+	}
+
+	ctx := r.Context()
+
+	var viewerID int64
+	if user := app.getUserContext(r); user != nil {
+		viewerID = user.ID
+	}
+
+	allowed, err := app.store.Media.CanView(ctx, id, viewerID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.notFound(w, r)
+		return
+	}
+
+	m, err := app.store.Media.Read(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	key, ok := m.Keys()[variant]
+	if !ok {
+		app.notFound(w, r)
+		return
+	}
+
+	url, err := app.storage.SignedURL(ctx, key, app.config.media.urlExpiry)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// Let clients reuse the redirect for a while, but not beyond the
+	// lifetime of the signed URL.
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(app.config.media.urlExpiry.Seconds()/2)))
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// DeleteMedia godoc
+//
+//	@Summary		Delete media
+//	@Description	Delete one of the current user's uploads. It disappears from any post it is attached to
+//	@Tags			media
+//	@Param			id	path	int	true	"Media ID"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse
+//	@Failure		404	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/media/{id} [delete]
+func (app *application) deleteMediaHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	user := app.getUserContext(r)
+
+	m, err := app.store.Media.Delete(ctx, id, user.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for _, key := range m.Keys() {
+		if err := app.storage.Delete(ctx, key); err != nil {
+			app.logger.Errorw("failed to delete blob", "key", key, "error", err)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadAvatar godoc
+//
+//	@Summary		Upload avatar
+//	@Description	Upload an image and use it as the current user's avatar
+//	@Tags			users
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			file	formData	file	true	"Image file"
+//	@Success		200		{object}	userResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		413		{object}	errorResponse
+//	@Failure		415		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/avatar [put]
+func (app *application) uploadAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := app.readUpload(w, r)
+	if err != nil {
+		app.uploadError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	user := *app.getUserContext(r)
+
+	m, err := app.storeMedia(ctx, user.ID, data)
+	if err != nil {
+		app.uploadError(w, r, err)
+		return
+	}
+
+	user.AvatarURL = fmt.Sprintf("%s/%d/content?variant=medium", app.config.media.baseURL, m.ID)
+	if err := app.store.Users.SetAvatar(ctx, &user, m.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.invalidateUserCache(ctx, user.ID)
+
+	if err := app.jsonResponse(w, http.StatusOK, userResponse{Data: user}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/auth"
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	mfaChallengeTokenType = "mfa"
+	recoveryCodeCount     = 10
+)
+
+type mfaChallenge struct {
+	MFAToken  string `json:"mfa_token"`
+	ExpiresIn int64  `json:"expires_in" example:"300"`
+}
+
+// completeLogin finishes a successful first-factor login. Users with 2FA
+// enabled receive a short-lived MFA challenge token instead of the final
+// tokens, which they exchange through verifyMFAHandler.
+func (app *application) completeLogin(w http.ResponseWriter, r *http.Request, user *store.User) {
+	ctx := r.Context()
+
+	totp, err := app.store.MFA.ReadTOTP(ctx, user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if totp != nil && totp.Enabled {
+		challenge, err := app.generateMFAChallengeToken(user.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if err := app.jsonResponse(w, http.StatusAccepted, mfaChallengeResponse{Data: *challenge}); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	tokens, err := app.issueTokens(r, user, false)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, authTokensResponse{Data: *tokens}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) generateMFAChallengeToken(userID int64) (*mfaChallenge, error) {
+	claims := jwt.MapClaims{
+		"sub": userID,
+		"typ": mfaChallengeTokenType,
+		"jti": uuid.New().String(),
+		"exp": time.Now().Add(app.config.auth.mfa.challengeExp).Unix(),
+		"nbf": time.Now().Unix(),
+		"iat": time.Now().Unix(),
+		"iss": app.config.auth.token.iss,
+		"aud": app.config.auth.token.aud,
+	}
+
+	token, err := app.authenticator.GenerateToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mfaChallenge{
+		MFAToken:  token,
+		ExpiresIn: int64(app.config.auth.mfa.challengeExp.Seconds()),
+	}, nil
+}
+
+// isMFARequired reports whether the user's role is at or above the level
+// configured to require two-factor authentication.
+func (app *application) isMFARequired(ctx context.Context, user *store.User) (bool, error) {
+	if app.config.auth.mfa.requiredRole == "" || user.Role == nil {
+		return false, nil
+	}
+
+	return app.checkRolePrecedence(ctx, user.Role.Level, app.config.auth.mfa.requiredRole)
+}
+
+// canSkipMFAVerification reports whether the user may authenticate without
+// a verified second factor: either their role does not require 2FA, or they
+// have enrolled and proved it when the credential was created.
+func (app *application) canSkipMFAVerification(ctx context.Context, user *store.User) (bool, error) {
+	required, err := app.isMFARequired(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	if !required {
+		return true, nil
+	}
+
+	totp, err := app.store.MFA.ReadTOTP(ctx, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return totp.Enabled, nil
+}
+
+type totpEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// EnrollTOTP godoc
+//
+//	@Summary		Enroll TOTP
+//	@Description	Generate a new TOTP secret for the current user. 2FA is enabled once the secret is confirmed with a code.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		201	{object}	totpEnrollmentResponse
+//	@Failure		401	{object}	errorResponse
+//	@Failure		409	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/authentication/mfa/totp [post]
+func (app *application) enrollTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	err = app.store.MFA.CreateTOTP(r.Context(), user.ID, secret)
+	if err != nil {
+		if err == store.ErrMFAAlreadyEnabled {
+			app.conflict(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	enrollment := totpEnrollment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(app.config.auth.mfa.issuer, user.Email, secret),
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, totpEnrollmentResponse{Data: enrollment}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type TOTPCodePayload struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type recoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ConfirmTOTP godoc
+//
+//	@Summary		Confirm TOTP
+//	@Description	Enable 2FA by confirming the pending TOTP secret with a code. Returns one-time recovery codes, which are only shown once.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		TOTPCodePayload	true	"TOTP code payload"
+//	@Success		200		{object}	recoveryCodesResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		409		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/authentication/mfa/totp/confirm [post]
+func (app *application) confirmTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var payload TOTPCodePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+	ctx := r.Context()
+
+	totp, err := app.store.MFA.ReadTOTP(ctx, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+	if totp.Enabled {
+		app.conflict(w, r, store.ErrMFAAlreadyEnabled)
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(totp.Secret, payload.Code, time.Now())
+	if !ok {
+		app.badRequest(w, r, fmt.Errorf("invalid code"))
+		return
+	}
+
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	hashedCodes := make([]string, len(codes))
+	for i, code := range codes {
+		hashedCodes[i] = hashToken(code)
+	}
+
+	err = app.store.MFA.EnableTOTP(ctx, user.ID, step, hashedCodes)
+	if err != nil {
+		if err == store.ErrMFAAlreadyEnabled {
+			app.conflict(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, recoveryCodesResponse{Data: recoveryCodes{RecoveryCodes: codes}}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// DisableTOTP godoc
+//
+//	@Summary		Disable TOTP
+//	@Description	Turn off 2FA for the current user and discard their recovery codes
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	TOTPCodePayload	true	"TOTP code payload"
+//	@Success		204		"2FA disabled"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		403		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/authentication/mfa/totp/disable [post]
+func (app *application) disableTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var payload TOTPCodePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+	ctx := r.Context()
+
+	required, err := app.isMFARequired(ctx, user)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if required {
+		app.forbidden(w, r, fmt.Errorf("two-factor authentication is required for role %s", user.Role.Name))
+		return
+	}
+
+	totp, err := app.store.MFA.ReadTOTP(ctx, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.useTOTPCode(ctx, totp, payload.Code); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := app.store.MFA.DisableTOTP(ctx, user.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type VerifyMFAPayload struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code,omitempty,max=32"`
+}
+
+// VerifyMFA godoc
+//
+//	@Summary		Verify MFA
+//	@Description	Exchange an MFA challenge token and a TOTP or recovery code for access and refresh tokens
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		VerifyMFAPayload	true	"Verify MFA payload"
+//	@Success		200		{object}	authTokensResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Router			/authentication/mfa/verify [post]
+func (app *application) verifyMFAHandler(w http.ResponseWriter, r *http.Request) {
+	var payload VerifyMFAPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	jwtToken, err := app.authenticator.ValidateToken(payload.MFAToken)
+	if err != nil {
+		app.unauthorized(w, r, fmt.Errorf("invalid mfa token"))
+		return
+	}
+
+	claims := jwtToken.Claims.(jwt.MapClaims)
+	if typ, _ := claims["typ"].(string); typ != mfaChallengeTokenType {
+		app.unauthorized(w, r, fmt.Errorf("invalid mfa token"))
+		return
+	}
+
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		app.unauthorized(w, r, fmt.Errorf("invalid mfa token"))
+		return
+	}
+	userID, err := strconv.ParseInt(fmt.Sprintf("%.0f", sub), 10, 64)
+	if err != nil {
+		app.unauthorized(w, r, fmt.Errorf("invalid mfa token"))
+		return
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		app.unauthorized(w, r, fmt.Errorf("invalid mfa token"))
+		return
+	}
+	expiresAt, err := jwtToken.Claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		app.unauthorized(w, r, fmt.Errorf("invalid mfa token"))
+		return
+	}
+
+	ctx := r.Context()
+
+	revoked, err := app.isTokenRevoked(ctx, jti)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if revoked {
+		app.unauthorized(w, r, fmt.Errorf("invalid mfa token"))
+		return
+	}
+
+	user, err := app.store.Users.ReadByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.unauthorized(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	totp, err := app.store.MFA.ReadTOTP(ctx, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.unauthorized(w, r, fmt.Errorf("two-factor authentication not enabled"))
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !totp.Enabled {
+		app.unauthorized(w, r, fmt.Errorf("two-factor authentication not enabled"))
+		return
+	}
+
+	if payload.Code != "" {
+		err = app.useTOTPCode(ctx, totp, payload.Code)
+	} else {
+		code := strings.ToLower(strings.TrimSpace(payload.RecoveryCode))
+		err = app.store.MFA.UseRecoveryCode(ctx, user.ID, hashToken(code))
+	}
+	if err != nil {
+		if err := app.recordMFAFailure(ctx, jti, user.ID, expiresAt.Time); err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		app.unauthorized(w, r, fmt.Errorf("invalid code"))
+		return
+	}
+
+	// The challenge is single use: a replayed token must not yield another
+	// pair of tokens.
+	if err := app.revokeToken(ctx, jti, user.ID, expiresAt.Time); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := app.store.LoginFailures.Reset(ctx, store.LoginFailureScopeMFA, jti); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	tokens, err := app.issueTokens(r, user, true)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, authTokensResponse{Data: *tokens}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// recordMFAFailure counts a wrong code against the challenge token and
+// revokes the token once maxAttempts codes were rejected, so the user has to
+// log in again.
+func (app *application) recordMFAFailure(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	failure, err := app.store.LoginFailures.RecordFailure(ctx, store.LoginFailureScopeMFA, jti, app.config.auth.mfa.challengeExp)
+	if err != nil {
+		return err
+	}
+
+	if failure.Failures < app.config.auth.mfa.maxAttempts {
+		return nil
+	}
+
+	if err := app.revokeToken(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+
+	return app.store.LoginFailures.Reset(ctx, store.LoginFailureScopeMFA, jti)
+}
+
+// useTOTPCode validates the code and marks its time step as used so it
+// cannot be presented again.
+func (app *application) useTOTPCode(ctx context.Context, totp *store.TOTP, code string) error {
+	step, ok := auth.ValidateTOTP(totp.Secret, code, time.Now())
+	if !ok {
+		return fmt.Errorf("invalid code")
+	}
+
+	if err := app.store.MFA.UseTOTPStep(ctx, totp.UserID, step); err != nil {
+		if err == store.ErrNotFound {
+			return fmt.Errorf("code already used")
+		}
+		return err
+	}
+
+	return nil
+}
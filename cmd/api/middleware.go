@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/andras-szesztai/social/internal/store"
 	"github.com/golang-jwt/jwt/v5"
@@ -53,6 +55,33 @@ func (app *application) BasicAuthMiddleware(next http.Handler) http.Handler {
 }
 
 func (app *application) AuthTokenMiddleware(next http.Handler) http.Handler {
+	return app.authenticate(next, true)
+}
+
+// OptionalAuthTokenMiddleware authenticates requests that carry an
+// Authorization header like AuthTokenMiddleware and lets anonymous requests
+// through without a user.
+func (app *application) OptionalAuthTokenMiddleware(next http.Handler) http.Handler {
+	authenticated := app.AuthTokenMiddleware(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
+// MFAEnrollmentAuthMiddleware authenticates like AuthTokenMiddleware but also
+// admits users whose role requires 2FA before they have enrolled, so they can
+// reach the enrollment endpoints.
+func (app *application) MFAEnrollmentAuthMiddleware(next http.Handler) http.Handler {
+	return app.authenticate(next, false)
+}
+
+func (app *application) authenticate(next http.Handler, enforceMFA bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -72,6 +101,35 @@ func (app *application) AuthTokenMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		if strings.HasPrefix(token, personalAccessTokenPrefix) {
+			ctx := r.Context()
+
+			pat, user, err := app.authenticatePersonalAccessToken(ctx, token)
+			if err != nil {
+				app.unauthorized(w, r, err)
+				return
+			}
+
+			// A personal access token carries no second factor, so it is only
+			// accepted for roles that require 2FA once the user has enrolled.
+			if enforceMFA {
+				allowed, err := app.canSkipMFAVerification(ctx, user)
+				if err != nil {
+					app.internalServerError(w, r, err)
+					return
+				}
+				if !allowed {
+					app.forbidden(w, r, fmt.Errorf("two-factor authentication required"))
+					return
+				}
+			}
+
+			ctx = context.WithValue(ctx, userContextKey, user)
+			ctx = context.WithValue(ctx, personalAccessTokenContextKey, pat)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		jwtToken, err := app.authenticator.ValidateToken(token)
 		if err != nil {
 			app.unauthorized(w, r, fmt.Errorf("invalid token"))
@@ -79,19 +137,93 @@ func (app *application) AuthTokenMiddleware(next http.Handler) http.Handler {
 		}
 
 		claims := jwtToken.Claims.(jwt.MapClaims)
-		userId, err := strconv.ParseInt(fmt.Sprintf("%.0f", claims["sub"].(float64)), 10, 64)
+		sub, ok := claims["sub"].(float64)
+		if !ok {
+			app.unauthorized(w, r, fmt.Errorf("invalid token"))
+			return
+		}
+		userId, err := strconv.ParseInt(fmt.Sprintf("%.0f", sub), 10, 64)
 		if err != nil {
 			app.unauthorized(w, r, fmt.Errorf("invalid token"))
 			return
 		}
 
-		user, err := app.getUser(r.Context(), userId)
+		if typ, ok := claims["typ"].(string); ok && typ == mfaChallengeTokenType {
+			app.unauthorized(w, r, fmt.Errorf("invalid token"))
+			return
+		}
+
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			app.unauthorized(w, r, fmt.Errorf("invalid token"))
+			return
+		}
+
+		generation, ok := claims["gen"].(float64)
+		if !ok {
+			app.unauthorized(w, r, fmt.Errorf("invalid token"))
+			return
+		}
+
+		ctx := r.Context()
+
+		revoked, err := app.isTokenRevoked(ctx, jti)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if revoked {
+			app.unauthorized(w, r, fmt.Errorf("token revoked"))
+			return
+		}
+
+		// Tokens issued before sessions were introduced carry no sid.
+		if sessionID, _ := claims["sid"].(string); sessionID != "" {
+			revoked, err := app.isSessionRevoked(ctx, sessionID)
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+			if revoked {
+				app.unauthorized(w, r, fmt.Errorf("session revoked"))
+				return
+			}
+
+			if err := app.store.Sessions.Touch(ctx, sessionID, clientIP(r)); err != nil {
+				app.logger.Errorw("failed to record session activity", "error", err)
+			}
+		}
+
+		currentGeneration, err := app.getTokenGeneration(ctx, userId)
 		if err != nil {
 			app.unauthorized(w, r, fmt.Errorf("invalid token"))
 			return
 		}
+		if int64(generation) != currentGeneration {
+			app.unauthorized(w, r, fmt.Errorf("token revoked"))
+			return
+		}
+
+		user, err := app.getUser(ctx, userId)
+		if err != nil {
+			app.unauthorized(w, r, fmt.Errorf("invalid token"))
+			return
+		}
+
+		if mfaVerified, _ := claims["mfa"].(bool); enforceMFA && !mfaVerified {
+			required, err := app.isMFARequired(ctx, user)
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+			if required {
+				app.forbidden(w, r, fmt.Errorf("two-factor authentication required"))
+				return
+			}
+		}
 
-		ctx := context.WithValue(r.Context(), contextKey("user"), user)
+		ctx = context.WithValue(ctx, userContextKey, user)
+		ctx = context.WithValue(ctx, tokenClaimsContextKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -121,6 +253,33 @@ func (app *application) checkPostOwnership(requiredRole string, next http.Handle
 	})
 }
 
+// requireRole only lets users through whose role is at least as high as
+// requiredRole.
+func (app *application) requireRole(requiredRole string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := app.getUserContext(r)
+			if user.Role == nil {
+				app.forbidden(w, r, fmt.Errorf("forbidden"))
+				return
+			}
+
+			allowed, err := app.checkRolePrecedence(r.Context(), user.Role.Level, requiredRole)
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+
+			if !allowed {
+				app.forbidden(w, r, fmt.Errorf("forbidden"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (app *application) checkRolePrecedence(ctx context.Context, userRoleID int64, requiredRole string) (bool, error) {
 	role, err := app.store.Roles.ReadByName(ctx, requiredRole)
 	if err != nil {
@@ -160,6 +319,84 @@ func (app *application) getUser(ctx context.Context, id int64) (*store.User, err
 	return user, nil
 }
 
+// invalidateUserCache drops the cached copy of the user after it changed.
+func (app *application) invalidateUserCache(ctx context.Context, userID int64) {
+	if !app.config.redis.enabled {
+		return
+	}
+
+	if err := app.cache.Users.Delete(ctx, userID); err != nil {
+		app.logger.Errorw("failed to invalidate user cache", "error", err)
+	}
+}
+
+func (app *application) isTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if !app.config.redis.enabled {
+		return app.store.RevokedTokens.IsRevoked(ctx, jti)
+	}
+
+	return app.cache.Tokens.IsRevoked(ctx, jti)
+}
+
+func (app *application) revokeToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	if !app.config.redis.enabled {
+		return app.store.RevokedTokens.Revoke(ctx, jti, userID, expiresAt)
+	}
+
+	return app.cache.Tokens.Revoke(ctx, jti, time.Until(expiresAt))
+}
+
+func (app *application) getTokenGeneration(ctx context.Context, userID int64) (int64, error) {
+	if !app.config.redis.enabled {
+		return app.store.Users.ReadTokenGeneration(ctx, userID)
+	}
+
+	generation, err := app.cache.Tokens.GetGeneration(ctx, userID)
+	if err == nil {
+		return generation, nil
+	}
+	if err != redis.Nil {
+		return 0, err
+	}
+
+	generation, err = app.store.Users.ReadTokenGeneration(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := app.cache.Tokens.SetGeneration(ctx, userID, generation); err != nil {
+		app.logger.Errorw("failed to set token generation in cache", "error", err)
+	}
+
+	return generation, nil
+}
+
+// revokeAllSessions invalidates every access and refresh token issued to the
+// user so far by bumping their token generation, and revokes their personal
+// access tokens.
+func (app *application) revokeAllSessions(ctx context.Context, userID int64) error {
+	generation, err := app.store.Users.IncrementTokenGeneration(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if app.config.redis.enabled {
+		if err := app.cache.Tokens.SetGeneration(ctx, userID, generation); err != nil {
+			return err
+		}
+	}
+
+	if err := app.store.Sessions.RevokeByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := app.store.PersonalAccessTokens.RevokeByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	return app.store.RefreshTokens.RevokeByUser(ctx, userID)
+}
+
 func (app *application) RateLimiterMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if app.config.rateLimiter.Enabled {
@@ -174,3 +411,14 @@ func (app *application) RateLimiterMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// clientIP returns the caller's address without the port. middleware.RealIP
+// has already replaced RemoteAddr with the forwarded address when present.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
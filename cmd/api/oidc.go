@@ -0,0 +1,455 @@
+package main
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/oidc"
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type oidcConfig struct {
+	providersFile string
+	requestExp    time.Duration
+}
+
+// oidcStateCookie binds a pending authorization to the browser that started
+// it, so a state leaked through the redirect cannot be completed elsewhere.
+const oidcStateCookie = "oidc_state"
+
+type oidcAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+type OIDCCallbackPayload struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// OIDCLogin godoc
+//
+//	@Summary		Start OIDC sign in
+//	@Description	Start signing in with an external identity provider. The client sends the user to the returned URL; the provider redirects back with a code and state to pass to the callback endpoint.
+//	@Tags			auth
+//	@Produce		json
+//	@Param			provider	path		string	true	"Provider name"
+//	@Success		200			{object}	oidcAuthorizationResponse
+//	@Failure		404			{object}	errorResponse
+//	@Failure		500			{object}	errorResponse
+//	@Router			/authentication/oidc/{provider}/login [post]
+func (app *application) oidcLoginHandler(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+	app.startOIDCAuthorization(w, r, nil, "/v1/authentication/oidc/"+provider+"/callback")
+}
+
+// OIDCCallback godoc
+//
+//	@Summary		Complete OIDC sign in
+//	@Description	Exchange the code the identity provider redirected back with for tokens like /authentication/token, creating an account on first use. The state must match the cookie set when the sign in was started.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			provider	path		string				true	"Provider name"
+//	@Param			payload		body		OIDCCallbackPayload	true	"OIDC callback payload"
+//	@Success		200			{object}	authTokensResponse
+//	@Success		202			{object}	mfaChallengeResponse
+//	@Failure		400			{object}	errorResponse
+//	@Failure		401			{object}	errorResponse
+//	@Failure		403			{object}	errorResponse
+//	@Failure		404			{object}	errorResponse
+//	@Failure		409			{object}	errorResponse
+//	@Failure		500			{object}	errorResponse
+//	@Router			/authentication/oidc/{provider}/callback [post]
+func (app *application) oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	identity, existing, ok := app.exchangeOIDCCallback(w, r, nil)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	var user *store.User
+	var err error
+	if existing != nil {
+		user, err = app.store.Users.ReadByID(ctx, existing.UserID)
+	} else {
+		user, err = app.signUpWithIdentity(r, identity)
+	}
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			app.unauthorized(w, r, fmt.Errorf("sign in with %s failed", identity.Provider))
+		case errEmailNotVerified, errMissingEmail:
+			app.forbidden(w, r, err)
+		case store.ErrEmailAlreadyExists:
+			app.conflict(w, r, fmt.Errorf("an account with this email is awaiting activation"))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.completeLogin(w, r, user)
+}
+
+// exchangeOIDCCallback checks the callback payload against the state cookie
+// and the pending authorization, then redeems the code at the provider. The
+// authorization must have been started for linkUserID, or as a sign in when
+// it is nil. It returns the identity and, if it was seen before, its link.
+func (app *application) exchangeOIDCCallback(w http.ResponseWriter, r *http.Request, linkUserID *int64) (*oidc.Identity, *store.Identity, bool) {
+	provider, ok := app.oidcProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		app.notFound(w, r)
+		return nil, nil, false
+	}
+
+	var payload OIDCCallbackPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return nil, nil, false
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return nil, nil, false
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(payload.State)) != 1 {
+		app.unauthorized(w, r, fmt.Errorf("invalid state"))
+		return nil, nil, false
+	}
+	app.setOIDCStateCookie(w, "", r.URL.Path, -1)
+
+	ctx := r.Context()
+
+	authRequest, err := app.store.Identities.ConsumeAuthRequest(ctx, hashToken(payload.State))
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.unauthorized(w, r, fmt.Errorf("invalid state"))
+		case store.ErrOIDCAuthRequestExpired:
+			app.unauthorized(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return nil, nil, false
+	}
+	if authRequest.Provider != provider.Name() {
+		app.unauthorized(w, r, fmt.Errorf("invalid state"))
+		return nil, nil, false
+	}
+	if (authRequest.UserID == nil) != (linkUserID == nil) || (linkUserID != nil && *authRequest.UserID != *linkUserID) {
+		app.unauthorized(w, r, fmt.Errorf("invalid state"))
+		return nil, nil, false
+	}
+
+	identity, err := provider.Exchange(ctx, payload.Code, authRequest.CodeVerifier, authRequest.Nonce)
+	if err != nil {
+		app.logger.Warnw("oidc code exchange failed", "provider", provider.Name(), "error", err)
+		app.unauthorized(w, r, fmt.Errorf("sign in with %s failed", provider.Name()))
+		return nil, nil, false
+	}
+
+	existing, err := app.store.Identities.ReadByProviderSubject(ctx, identity.Provider, identity.Subject)
+	if err != nil && err != sql.ErrNoRows {
+		app.internalServerError(w, r, err)
+		return nil, nil, false
+	}
+
+	return identity, existing, true
+}
+
+var (
+	errEmailNotVerified = fmt.Errorf("the identity provider has not verified the email")
+	errMissingEmail     = fmt.Errorf("the identity provider did not share an email")
+)
+
+// signUpWithIdentity resolves an identity seen for the first time. It is
+// linked to the activated account with the same email, or else a new account
+// is created. Both require the provider to have verified the email, otherwise
+// anyone could claim an existing account by its address.
+func (app *application) signUpWithIdentity(r *http.Request, identity *oidc.Identity) (*store.User, error) {
+	ctx := r.Context()
+
+	if identity.Email == "" {
+		return nil, errMissingEmail
+	}
+	if !identity.EmailVerified {
+		return nil, errEmailNotVerified
+	}
+
+	link := &store.Identity{Provider: identity.Provider, Subject: identity.Subject, Email: identity.Email}
+
+	user, err := app.store.Users.ReadByEmail(ctx, identity.Email)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if user != nil {
+		link.UserID = user.ID
+		if err := app.store.Identities.Create(ctx, link); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	// The account gets a random password nobody knows; it can be replaced
+	// through the password reset flow.
+	user = &store.User{Email: identity.Email, Role: &store.Role{Name: "user"}}
+	user.Password.Set(uuid.New().String())
+
+	base := usernameFromIdentity(identity)
+	for attempt := 0; ; attempt++ {
+		user.Username = base
+		if attempt > 0 {
+			user.Username = fmt.Sprintf("%s_%s", base, uuid.New().String()[:5])
+		}
+
+		err = app.store.Users.CreateWithIdentity(ctx, user, link)
+		if err != store.ErrUsernameAlreadyExists || attempt == 4 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return app.store.Users.ReadByID(ctx, user.ID)
+}
+
+// LinkIdentityCallback godoc
+//
+//	@Summary		Complete identity link
+//	@Description	Exchange the code the identity provider redirected back with and link the identity to the current user. The link must have been started by the same user.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			provider	path		string				true	"Provider name"
+//	@Param			payload		body		OIDCCallbackPayload	true	"OIDC callback payload"
+//	@Success		201			{object}	identityResponse
+//	@Failure		400			{object}	errorResponse
+//	@Failure		401			{object}	errorResponse
+//	@Failure		404			{object}	errorResponse
+//	@Failure		409			{object}	errorResponse
+//	@Failure		500			{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/identities/{provider}/callback [post]
+func (app *application) linkIdentityCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+
+	identity, existing, ok := app.exchangeOIDCCallback(w, r, &user.ID)
+	if !ok {
+		return
+	}
+
+	app.linkIdentity(w, r, user.ID, identity, existing)
+}
+
+func (app *application) linkIdentity(w http.ResponseWriter, r *http.Request, userID int64, identity *oidc.Identity, existing *store.Identity) {
+	if existing != nil {
+		if existing.UserID != userID {
+			app.conflict(w, r, store.ErrIdentityAlreadyLinked)
+			return
+		}
+
+		if err := app.jsonResponse(w, http.StatusCreated, identityResponse{Data: *existing}); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	link := store.Identity{UserID: userID, Provider: identity.Provider, Subject: identity.Subject, Email: identity.Email}
+	if err := app.store.Identities.Create(r.Context(), &link); err != nil {
+		if err == store.ErrIdentityAlreadyLinked {
+			app.conflict(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, identityResponse{Data: link}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// LinkIdentity godoc
+//
+//	@Summary		Link identity
+//	@Description	Start linking an external identity provider account to the current user. Complete it through the identity link callback endpoint.
+//	@Tags			users
+//	@Produce		json
+//	@Param			provider	path		string	true	"Provider name"
+//	@Success		200			{object}	oidcAuthorizationResponse
+//	@Failure		401			{object}	errorResponse
+//	@Failure		404			{object}	errorResponse
+//	@Failure		500			{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/identities/{provider} [post]
+func (app *application) linkIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+	provider := chi.URLParam(r, "provider")
+	app.startOIDCAuthorization(w, r, &user.ID, "/v1/users/me/identities/"+provider+"/callback")
+}
+
+// GetIdentities godoc
+//
+//	@Summary		List identities
+//	@Description	List the external identity provider accounts linked to the current user
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{object}	identitiesResponse
+//	@Failure		401	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/identities [get]
+func (app *application) getIdentitiesHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+
+	identities, err := app.store.Identities.ReadByUserID(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, identitiesResponse{Data: identities}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// UnlinkIdentity godoc
+//
+//	@Summary		Unlink identity
+//	@Description	Remove a linked external identity provider account from the current user
+//	@Tags			users
+//	@Produce		json
+//	@Param			identityID	path	int	true	"Identity ID"
+//	@Success		204			"Identity unlinked"
+//	@Failure		400			{object}	errorResponse
+//	@Failure		401			{object}	errorResponse
+//	@Failure		404			{object}	errorResponse
+//	@Failure		500			{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/identities/{identityID} [delete]
+func (app *application) unlinkIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	identityID, err := strconv.ParseInt(chi.URLParam(r, "identityID"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	err = app.store.Identities.Delete(r.Context(), identityID, user.ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// startOIDCAuthorization stores a pending sign in with fresh state, nonce
+// and PKCE verifier and returns the provider URL to send the user to.
+// linkUserID is set when the identity is to be linked to that user.
+// callbackPath is the endpoint that finishes the flow; the state cookie is
+// only sent there.
+func (app *application) startOIDCAuthorization(w http.ResponseWriter, r *http.Request, linkUserID *int64, callbackPath string) {
+	provider, ok := app.oidcProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		app.notFound(w, r)
+		return
+	}
+
+	var values [3]string
+	for i := range values {
+		value, err := oidc.RandomString()
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		values[i] = value
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	ctx := r.Context()
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	err = app.store.Identities.CreateAuthRequest(ctx, &store.OIDCAuthRequest{
+		State:        hashToken(state),
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		UserID:       linkUserID,
+		ExpiresAt:    time.Now().Add(app.config.auth.oidc.requestExp),
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.setOIDCStateCookie(w, state, callbackPath, int(app.config.auth.oidc.requestExp.Seconds()))
+
+	if err := app.jsonResponse(w, http.StatusOK, oidcAuthorizationResponse{Data: oidcAuthorization{AuthorizationURL: authURL}}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// setOIDCStateCookie stores the state for the callback to check against.
+// It is scoped to the callback path. A negative maxAge removes it.
+func (app *application) setOIDCStateCookie(w http.ResponseWriter, state, path string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   app.config.env == "production",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+var usernameDisallowed = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// usernameFromIdentity derives a username from the provider's preferred
+// username or the email's local part. It leaves room for a collision suffix
+// within the 20 characters registration allows.
+func usernameFromIdentity(identity *oidc.Identity) string {
+	base := identity.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	base = usernameDisallowed.ReplaceAllString(strings.ToLower(base), "_")
+	base = strings.Trim(base, "_")
+	if len(base) > 14 {
+		base = base[:14]
+	}
+	if base == "" {
+		base = "user"
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	return base
+}
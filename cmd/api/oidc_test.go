@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/oidc"
+	"github.com/andras-szesztai/social/internal/store"
+)
+
+func newOIDCTestApplication(t *testing.T) (*application, *oidc.MockServer) {
+	t.Helper()
+
+	server, err := oidc.NewMockServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	app := newTestApplication(t, withCachedUsers(&store.User{ID: 1, Role: &store.Role{Name: "user"}}))
+	app.oidcProviders = map[string]oidc.Provider{"mock": oidc.NewClient(server.Config("mock"), nil)}
+	app.config.auth.oidc.requestExp = time.Minute
+
+	return app, server
+}
+
+// startOIDC begins an authorization at path, follows it at the mock provider
+// and returns the callback payload with the state cookie that was set.
+func startOIDC(t *testing.T, app *application, server *oidc.MockServer, path, token string) (OIDCCallbackPayload, *http.Cookie) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rr := executeRequest(req, app.mountRoutes())
+	checkResponseCode(t, http.StatusOK, rr.Code)
+
+	var res oidcAuthorizationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+
+	var cookie *http.Cookie
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == oidcStateCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || !cookie.HttpOnly {
+		t.Fatal("expected an HttpOnly state cookie")
+	}
+
+	code, state, err := server.Authorize(res.Data.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return OIDCCallbackPayload{Code: code, State: state}, cookie
+}
+
+func oidcCallbackRequest(t *testing.T, path string, payload OIDCCallbackPayload, cookie *http.Cookie, token string) *http.Request {
+	t.Helper()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return req
+}
+
+func TestOIDCLogin(t *testing.T) {
+	const (
+		loginPath    = "/v1/authentication/oidc/mock/login"
+		callbackPath = "/v1/authentication/oidc/mock/callback"
+	)
+
+	t.Run("should sign in with the state cookie", func(t *testing.T) {
+		app, server := newOIDCTestApplication(t)
+		mux := app.mountRoutes()
+
+		payload, cookie := startOIDC(t, app, server, loginPath, "")
+		if cookie.Path != callbackPath || cookie.SameSite != http.SameSiteLaxMode {
+			t.Errorf("expected a lax state cookie scoped to %s, got path %q", callbackPath, cookie.Path)
+		}
+
+		rr := executeRequest(oidcCallbackRequest(t, callbackPath, payload, cookie, ""), mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		// The state is single use.
+		rr = executeRequest(oidcCallbackRequest(t, callbackPath, payload, cookie, ""), mux)
+		checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should reject a callback without the state cookie", func(t *testing.T) {
+		app, server := newOIDCTestApplication(t)
+
+		payload, _ := startOIDC(t, app, server, loginPath, "")
+
+		rr := executeRequest(oidcCallbackRequest(t, callbackPath, payload, nil, ""), app.mountRoutes())
+		checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should reject an unknown state", func(t *testing.T) {
+		app, _ := newOIDCTestApplication(t)
+
+		payload := OIDCCallbackPayload{Code: "code", State: "unknown"}
+		cookie := &http.Cookie{Name: oidcStateCookie, Value: payload.State}
+
+		rr := executeRequest(oidcCallbackRequest(t, callbackPath, payload, cookie, ""), app.mountRoutes())
+		checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should reject an identity whose account is gone", func(t *testing.T) {
+		app, server := newOIDCTestApplication(t)
+		app.store.Users.(*store.MockUserStore).ReadByIDFunc = func(ctx context.Context, id int64) (*store.User, error) {
+			return nil, sql.ErrNoRows
+		}
+		identities := app.store.Identities.(*store.MockIdentityStore)
+		identities.Identities = []store.Identity{{ID: 1, UserID: 2, Provider: "mock", Subject: "mock-subject"}}
+
+		payload, cookie := startOIDC(t, app, server, loginPath, "")
+
+		rr := executeRequest(oidcCallbackRequest(t, callbackPath, payload, cookie, ""), app.mountRoutes())
+		checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestOIDCLink(t *testing.T) {
+	const (
+		linkPath     = "/v1/users/me/identities/mock"
+		callbackPath = "/v1/users/me/identities/mock/callback"
+	)
+
+	t.Run("should require authentication", func(t *testing.T) {
+		app, _ := newOIDCTestApplication(t)
+		mux := app.mountRoutes()
+
+		rr := executeRequest(httptest.NewRequest(http.MethodPost, linkPath, nil), mux)
+		checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+
+		payload := OIDCCallbackPayload{Code: "code", State: "state"}
+		rr = executeRequest(oidcCallbackRequest(t, callbackPath, payload, nil, ""), mux)
+		checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should link the identity to the caller", func(t *testing.T) {
+		app, server := newOIDCTestApplication(t)
+		token, _ := app.authenticator.GenerateToken(nil)
+
+		payload, cookie := startOIDC(t, app, server, linkPath, token)
+		if cookie.Path != callbackPath {
+			t.Errorf("expected the state cookie to be scoped to %s, got %q", callbackPath, cookie.Path)
+		}
+
+		rr := executeRequest(oidcCallbackRequest(t, callbackPath, payload, cookie, token), app.mountRoutes())
+		checkResponseCode(t, http.StatusCreated, rr.Code)
+
+		identities := app.store.Identities.(*store.MockIdentityStore).Identities
+		if len(identities) != 1 || identities[0].UserID != 1 {
+			t.Errorf("expected the identity to be linked to user 1, got %+v", identities)
+		}
+	})
+
+	t.Run("should not complete a link as a sign in", func(t *testing.T) {
+		app, server := newOIDCTestApplication(t)
+		token, _ := app.authenticator.GenerateToken(nil)
+
+		payload, cookie := startOIDC(t, app, server, linkPath, token)
+
+		rr := executeRequest(oidcCallbackRequest(t, "/v1/authentication/oidc/mock/callback", payload, cookie, ""), app.mountRoutes())
+		checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should not complete a sign in as a link", func(t *testing.T) {
+		app, server := newOIDCTestApplication(t)
+		token, _ := app.authenticator.GenerateToken(nil)
+
+		payload, cookie := startOIDC(t, app, server, "/v1/authentication/oidc/mock/login", "")
+
+		rr := executeRequest(oidcCallbackRequest(t, callbackPath, payload, cookie, token), app.mountRoutes())
+		checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+
+		if identities := app.store.Identities.(*store.MockIdentityStore).Identities; len(identities) != 0 {
+			t.Errorf("expected no linked identity, got %+v", identities)
+		}
+	})
+}
+
+func TestOIDCExchangeMismatch(t *testing.T) {
+	const callbackPath = "/v1/authentication/oidc/mock/callback"
+
+	tests := []struct {
+		name   string
+		tamper func(req *store.OIDCAuthRequest)
+	}{
+		{
+			name:   "should reject a PKCE verifier mismatch",
+			tamper: func(req *store.OIDCAuthRequest) { req.CodeVerifier = "wrong-verifier" },
+		},
+		{
+			name:   "should reject a nonce mismatch",
+			tamper: func(req *store.OIDCAuthRequest) { req.Nonce = "wrong-nonce" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, server := newOIDCTestApplication(t)
+
+			payload, cookie := startOIDC(t, app, server, "/v1/authentication/oidc/mock/login", "")
+			for _, req := range app.store.Identities.(*store.MockIdentityStore).AuthRequests {
+				tt.tamper(req)
+			}
+
+			rr := executeRequest(oidcCallbackRequest(t, callbackPath, payload, cookie, ""), app.mountRoutes())
+			checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/andras-szesztai/social/internal/mailer"
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/google/uuid"
+)
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ForgotPassword godoc
+//
+//	@Summary		Forgot password
+//	@Description	Email a password reset link to the account with the given email, if there is one
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	ForgotPasswordPayload	true	"Forgot password payload"
+//	@Success		202		"Reset link sent if the account exists"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Router			/authentication/password/forgot [post]
+func (app *application) forgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ForgotPasswordPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	// The response is the same whether or not the account exists so the
+	// endpoint cannot be used to enumerate registered emails.
+	user, err := app.store.Users.ReadByEmail(ctx, payload.Email)
+	if err != nil && err != sql.ErrNoRows {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if user != nil {
+		token := uuid.New().String()
+
+		err := app.store.Users.CreatePasswordReset(ctx, user.ID, hashToken(token), app.config.mail.resetExpiry)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		err = app.mailer.Send(mailer.PasswordResetTemplate, user.Username, user.Email, map[string]any{
+			"Username": user.Username,
+			"ResetURL": fmt.Sprintf("%s/reset-password/%s", app.config.frontendURL, token),
+			"Expiry":   app.config.mail.resetExpiry.String(),
+		}, app.config.env == "production")
+		if err != nil {
+			app.logger.Errorw("failed to send password reset email", "error", err)
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusAccepted, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type ResetPasswordPayload struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+// ResetPassword godoc
+//
+//	@Summary		Reset password
+//	@Description	Set a new password using a reset token and sign out every existing session
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	ResetPasswordPayload	true	"Reset password payload"
+//	@Success		204		"Password reset"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Router			/authentication/password/reset [post]
+func (app *application) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ResetPasswordPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	token := hashToken(payload.Token)
+
+	userID, err := app.store.Users.ReadPasswordReset(ctx, token)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFound(w, r)
+		case store.ErrPasswordResetExpired:
+			app.badRequest(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	owner, err := app.store.Users.ReadByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.passwordPolicy.Validate(payload.Password, owner.Username, owner.Email); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	var user store.User
+	user.Password.Set(payload.Password)
+
+	err = app.store.Users.ResetPassword(ctx, token, &user)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFound(w, r)
+		case store.ErrPasswordResetExpired:
+			app.badRequest(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.revokeAllSessions(ctx, user.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"current_password" validate:"required,max=72"`
+	NewPassword     string `json:"new_password" validate:"required,max=72"`
+}
+
+// ChangePassword godoc
+//
+//	@Summary		Change password
+//	@Description	Change the current user's password. Every existing session is signed out and new tokens are returned for this one.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ChangePasswordPayload	true	"Change password payload"
+//	@Success		200		{object}	authTokensResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/password [put]
+func (app *application) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ChangePasswordPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	// The cached user does not carry the password hash.
+	user, err := app.store.Users.ReadByID(ctx, app.getUserContext(r).ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := user.Password.Compare(payload.CurrentPassword); err != nil {
+		app.unauthorized(w, r, fmt.Errorf("invalid credentials"))
+		return
+	}
+
+	if payload.NewPassword == payload.CurrentPassword {
+		app.badRequest(w, r, fmt.Errorf("new password must differ from the current one"))
+		return
+	}
+
+	if err := app.passwordPolicy.Validate(payload.NewPassword, user.Username, user.Email); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user.Password.Set(payload.NewPassword)
+
+	if err := app.store.Users.UpdatePassword(ctx, user); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.revokeAllSessions(ctx, user.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	mfaVerified, _ := app.getTokenClaimsContext(r)["mfa"].(bool)
+	tokens, err := app.issueTokens(r, user, mfaVerified)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, authTokensResponse{Data: *tokens}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
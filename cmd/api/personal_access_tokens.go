@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// personalAccessTokenPrefix lets AuthTokenMiddleware tell personal access
+// tokens apart from JWTs without trying to parse them.
+const personalAccessTokenPrefix = "pat_"
+
+const (
+	scopePostsRead      = "posts:read"
+	scopePostsWrite     = "posts:write"
+	scopeCommentsWrite  = "comments:write"
+	scopeReactionsWrite = "reactions:write"
+	scopeFeedRead       = "feed:read"
+	scopeUsersRead      = "users:read"
+	scopeUsersWrite     = "users:write"
+)
+
+type CreatePersonalAccessTokenPayload struct {
+	Name          string   `json:"name" validate:"required,max=255"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=posts:read posts:write comments:write reactions:write feed:read users:read users:write"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+}
+
+type createdPersonalAccessToken struct {
+	store.PersonalAccessToken
+	Token string `json:"token"`
+}
+
+// CreatePersonalAccessToken godoc
+//
+//	@Summary		Create personal access token
+//	@Description	Create a named, scoped token for automation. The token is only returned once.
+//	@Tags			tokens
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreatePersonalAccessTokenPayload	true	"Create personal access token payload"
+//	@Success		201		{object}	createdPersonalAccessTokenResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/tokens [post]
+func (app *application) createPersonalAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreatePersonalAccessTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	token := personalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	scopes := slices.Clone(payload.Scopes)
+	slices.Sort(scopes)
+
+	pat := store.PersonalAccessToken{
+		UserID: user.ID,
+		Name:   payload.Name,
+		Token:  hashToken(token),
+		Scopes: slices.Compact(scopes),
+	}
+	if payload.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, payload.ExpiresInDays)
+		pat.ExpiresAt = &expiresAt
+	}
+
+	if err := app.store.PersonalAccessTokens.Create(r.Context(), &pat); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	created := createdPersonalAccessToken{PersonalAccessToken: pat, Token: token}
+	if err := app.jsonResponse(w, http.StatusCreated, createdPersonalAccessTokenResponse{Data: created}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetPersonalAccessTokens godoc
+//
+//	@Summary		List personal access tokens
+//	@Description	List the current user's active personal access tokens
+//	@Tags			tokens
+//	@Produce		json
+//	@Success		200	{object}	personalAccessTokensResponse
+//	@Failure		401	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/tokens [get]
+func (app *application) getPersonalAccessTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+
+	tokens, err := app.store.PersonalAccessTokens.ReadByUserID(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, personalAccessTokensResponse{Data: tokens}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// RevokePersonalAccessToken godoc
+//
+//	@Summary		Revoke personal access token
+//	@Description	Revoke one of the current user's personal access tokens
+//	@Tags			tokens
+//	@Produce		json
+//	@Param			tokenID	path	int	true	"Token ID"
+//	@Success		204		"Token revoked"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/tokens/{tokenID} [delete]
+func (app *application) revokePersonalAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := strconv.ParseInt(chi.URLParam(r, "tokenID"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	err = app.store.PersonalAccessTokens.Revoke(r.Context(), tokenID, user.ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// authenticatePersonalAccessToken resolves a personal access token to its
+// owner, rejecting revoked and expired tokens.
+func (app *application) authenticatePersonalAccessToken(ctx context.Context, token string) (*store.PersonalAccessToken, *store.User, error) {
+	pat, err := app.store.PersonalAccessTokens.ReadByToken(ctx, hashToken(token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("invalid token")
+		}
+		return nil, nil, err
+	}
+
+	if pat.RevokedAt != nil {
+		return nil, nil, fmt.Errorf("token revoked")
+	}
+	if pat.ExpiresAt != nil && time.Now().After(*pat.ExpiresAt) {
+		return nil, nil, fmt.Errorf("token expired")
+	}
+
+	user, err := app.getUser(ctx, pat.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.DeactivatedAt != nil {
+		return nil, nil, fmt.Errorf("account deactivated")
+	}
+
+	if err := app.store.PersonalAccessTokens.Touch(ctx, pat.ID); err != nil {
+		app.logger.Errorw("failed to record personal access token use", "error", err)
+	}
+
+	return pat, user, nil
+}
+
+// requireScope limits personal access tokens to routes covered by one of
+// their scopes. Regular sessions are not scoped and always pass.
+func (app *application) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pat := app.getPersonalAccessTokenContext(r)
+			if pat != nil && !slices.Contains(pat.Scopes, scope) {
+				app.forbidden(w, r, fmt.Errorf("token is missing scope %s", scope))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireSession rejects personal access tokens on routes that manage
+// credentials, so a leaked token cannot mint new ones or change 2FA.
+func (app *application) requireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.getPersonalAccessTokenContext(r) != nil {
+			app.forbidden(w, r, fmt.Errorf("personal access tokens are not allowed"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+const personalAccessTokenContextKey = contextKey("personalAccessToken")
+
+func (app *application) getPersonalAccessTokenContext(r *http.Request) *store.PersonalAccessToken {
+	pat, _ := r.Context().Value(personalAccessTokenContextKey).(*store.PersonalAccessToken)
+	return pat
+}
@@ -3,35 +3,76 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/andras-szesztai/social/internal/store"
 	"github.com/go-chi/chi/v5"
 )
 
 type createPostRequest struct {
-	Title   string   `json:"title" validate:"required,max=255"`
-	Content string   `json:"content" validate:"required,max=1000"`
-	Tags    []string `json:"tags" validate:"required,max=10"`
+	Title    string   `json:"title" validate:"required,max=255"`
+	Content  string   `json:"content" validate:"required,max=1000"`
+	Tags     []string `json:"tags" validate:"required,max=10"`
+	MediaIDs []int64  `json:"media_ids" validate:"omitempty,max=4,unique"`
+	// QuotedPostID makes this a quote post of another post.
+	QuotedPostID *int64 `json:"quoted_post_id" validate:"omitempty,gt=0"`
+	// Status defaults to published. Scheduled posts need PublishAt.
+	Status    string     `json:"status" validate:"omitempty,oneof=draft scheduled published" example:"published"`
+	PublishAt *time.Time `json:"publish_at" example:"2030-01-01T00:00:00Z"`
 }
 
 type updatePostRequest struct {
-	Title   string   `json:"title" validate:"omitempty,max=255"`
-	Content string   `json:"content" validate:"omitempty,max=1000"`
-	Tags    []string `json:"tags" validate:"omitempty,max=10"`
+	Title     string     `json:"title" validate:"omitempty,max=255"`
+	Content   string     `json:"content" validate:"omitempty,max=1000"`
+	Tags      []string   `json:"tags" validate:"omitempty,max=10"`
+	Status    string     `json:"status" validate:"omitempty,oneof=draft scheduled published" example:"published"`
+	PublishAt *time.Time `json:"publish_at" example:"2030-01-01T00:00:00Z"`
+}
+
+var errPublishedPost = errors.New("published posts cannot be turned back into drafts")
+
+// validatePostSchedule checks that publishAt is a future time given exactly
+// when the post is scheduled.
+func validatePostSchedule(status string, publishAt *time.Time) error {
+	if status != store.PostStatusScheduled {
+		if publishAt != nil {
+			return errors.New("publish_at can only be set on scheduled posts")
+		}
+		return nil
+	}
+
+	if publishAt == nil {
+		return errors.New("scheduled posts need publish_at")
+	}
+	if !publishAt.After(time.Now()) {
+		return errors.New("publish_at must be in the future")
+	}
+
+	return nil
+}
+
+// sameTime reports whether a and b are both unset or the same instant.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
 }
 
 // CreatePost godoc
 //
 //	@Summary		Create post
-//	@Description	Create a new post
+//	@Description	Create a new post. Setting quoted_post_id quotes another post, which must not belong to a private account. Posts can be kept as drafts or scheduled for publish_at, and are only visible to their author until published
 //	@Tags			posts
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		createPostRequest	true	"Create post request"
 //	@Success		201		{object}	postResponse
 //	@Failure		400		{object}	errorResponse
+//	@Failure		403		{object}	errorResponse	"The quoted post belongs to a private account"
 //	@Failure		500		{object}	errorResponse
 //	@Security		ApiKeyAuth
 //	@Router			/posts [post]
@@ -47,18 +88,45 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if payload.Status == "" {
+		payload.Status = store.PostStatusPublished
+	}
+	if err := validatePostSchedule(payload.Status, payload.PublishAt); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
 	user := app.getUserContext(r)
 
+	ctx := r.Context()
+	if payload.QuotedPostID != nil {
+		if !app.checkQuotable(w, r, *payload.QuotedPostID) {
+			return
+		}
+	}
+
 	post := store.Post{
-		Title:   payload.Title,
-		Content: payload.Content,
-		Tags:    payload.Tags,
-		UserID:  user.ID,
+		Title:        payload.Title,
+		Content:      payload.Content,
+		Tags:         payload.Tags,
+		UserID:       user.ID,
+		MediaIDs:     payload.MediaIDs,
+		QuotedPostID: payload.QuotedPostID,
+		Status:       payload.Status,
+		PublishAt:    payload.PublishAt,
 	}
 
-	ctx := r.Context()
 	createdPost, err := app.store.Posts.Create(ctx, &post)
 	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.badRequest(w, r, errors.New("media or quoted post not found"))
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.loadPostMedia(ctx, createdPost); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
@@ -83,15 +151,92 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 //	@Router			/posts/{id} [get]
 func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 	post := app.getPostContext(r)
+
+	ctx := r.Context()
+	if err := app.loadPostMedia(ctx, post); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.loadPostReactions(ctx, post, app.getUserContext(r).ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
 	if err := app.jsonResponse(w, http.StatusOK, postResponse{Data: *post}); err != nil {
 		app.internalServerError(w, r, err)
 	}
 }
 
+// checkQuotable responds with bad request unless the quoted post exists and
+// the user can see it, and with forbidden when it cannot be shared.
+func (app *application) checkQuotable(w http.ResponseWriter, r *http.Request, quotedPostID int64) bool {
+	ctx := r.Context()
+	user := app.getUserContext(r)
+
+	quoted, err := app.store.Posts.Read(ctx, quotedPostID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.badRequest(w, r, errors.New("quoted post not found"))
+			return false
+		}
+		app.internalServerError(w, r, err)
+		return false
+	}
+
+	if quoted.Status != store.PostStatusPublished && quoted.UserID != user.ID {
+		app.badRequest(w, r, errors.New("quoted post not found"))
+		return false
+	}
+
+	if quoted.UserID != user.ID {
+		allowed, err := app.store.Users.CanViewPosts(ctx, quoted.UserID, user.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return false
+		}
+		if !allowed {
+			app.badRequest(w, r, errors.New("quoted post not found"))
+			return false
+		}
+	}
+
+	if err := app.checkShareable(ctx, quoted, user.ID); err != nil {
+		switch {
+		case errors.Is(err, errUnpublishedPost):
+			app.badRequest(w, r, err)
+		case errors.Is(err, errPrivatePost):
+			app.forbidden(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return false
+	}
+
+	return true
+}
+
+// loadPostMedia attaches the post's media with signed download URLs.
+func (app *application) loadPostMedia(ctx context.Context, post *store.Post) error {
+	media, err := app.store.Media.ReadByPostID(ctx, post.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range media {
+		if err := app.signMedia(ctx, &media[i]); err != nil {
+			return err
+		}
+	}
+	post.Media = media
+
+	return nil
+}
+
 // UpdatePost godoc
 //
 //	@Summary		Update post
-//	@Description	Update a post by id
+//	@Description	Update a post by id. The previous version is kept as a revision. Drafts and scheduled posts can be rescheduled or published by changing their status, published posts cannot be unpublished
 //	@Tags			posts
 //	@Accept			json
 //	@Produce		json
@@ -100,6 +245,7 @@ func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 //	@Success		200		{object}	postResponse
 //	@Failure		400		{object}	errorResponse
 //	@Failure		404		{object}	errorResponse
+//	@Failure		409		{object}	errorResponse	"The post was changed in the meantime"
 //	@Failure		500		{object}	errorResponse
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{id} [put]
@@ -126,18 +272,41 @@ func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request
 	if len(payload.Tags) == 0 {
 		payload.Tags = post.Tags
 	}
+	if payload.Status == "" {
+		payload.Status = post.Status
+		if payload.PublishAt == nil {
+			payload.PublishAt = post.PublishAt
+		}
+	}
+
+	if post.Status == store.PostStatusPublished && payload.Status != store.PostStatusPublished {
+		app.badRequest(w, r, errPublishedPost)
+		return
+	}
+	if payload.Status != post.Status || !sameTime(payload.PublishAt, post.PublishAt) {
+		if err := validatePostSchedule(payload.Status, payload.PublishAt); err != nil {
+			app.badRequest(w, r, err)
+			return
+		}
+	}
 
 	postToUpdate := store.Post{
-		ID:      post.ID,
-		Title:   payload.Title,
-		Content: payload.Content,
-		Tags:    payload.Tags,
-		Version: post.Version,
+		ID:        post.ID,
+		Title:     payload.Title,
+		Content:   payload.Content,
+		Tags:      payload.Tags,
+		Version:   post.Version,
+		Status:    payload.Status,
+		PublishAt: payload.PublishAt,
 	}
 
 	ctx := r.Context()
 	updatedPost, err := app.store.Posts.Update(ctx, &postToUpdate)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.conflict(w, r, errPostChanged)
+			return
+		}
 		app.internalServerError(w, r, err)
 		return
 	}
@@ -147,6 +316,38 @@ func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// GetDrafts godoc
+//
+//	@Summary		Get drafts
+//	@Description	List your drafts and scheduled posts. Scheduled posts come first by when they are due, then drafts by when they were last edited
+//	@Tags			posts
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit"		default(20)
+//	@Param			offset	query		int	false	"Offset"	default(0)
+//	@Success		200		{object}	postsResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/drafts [get]
+func (app *application) getDraftsHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := app.readPagination(w, r)
+	if !ok {
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	drafts, err := app.store.Posts.ReadDrafts(r.Context(), user.ID, page)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, postsResponse{Data: drafts}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
 // DeletePost godoc
 //
 //	@Summary		Delete post
@@ -196,6 +397,10 @@ func (app *application) postsContextMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		if !app.canViewPost(w, r, post) {
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), postContextKey, post)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -205,3 +410,39 @@ func (app *application) getPostContext(r *http.Request) *store.Post {
 	post, _ := r.Context().Value(postContextKey).(*store.Post)
 	return post
 }
+
+// canViewPost checks whether the authenticated user may read the post.
+// Drafts and scheduled posts are only shown to their author.
+func (app *application) canViewPost(w http.ResponseWriter, r *http.Request, post *store.Post) bool {
+	if post.Status != store.PostStatusPublished {
+		if user := app.getUserContext(r); user == nil || user.ID != post.UserID {
+			app.notFound(w, r)
+			return false
+		}
+	}
+
+	return app.canViewPosts(w, r, post.UserID)
+}
+
+// canViewPosts checks whether the authenticated user may read the owner's
+// posts and their comments. Posts are hidden between users who blocked one
+// another and private accounts only show them to approved followers. It
+// responds with not found otherwise, so the post's existence is not leaked.
+func (app *application) canViewPosts(w http.ResponseWriter, r *http.Request, ownerID int64) bool {
+	user := app.getUserContext(r)
+	if user == nil || user.ID == ownerID {
+		return true
+	}
+
+	allowed, err := app.store.Users.CanViewPosts(r.Context(), ownerID, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return false
+	}
+	if !allowed {
+		app.notFound(w, r)
+		return false
+	}
+
+	return true
+}
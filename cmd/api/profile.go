@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+)
+
+// UpdateProfilePayload only changes the fields that are present. Sending an
+// empty string or list clears a field.
+type UpdateProfilePayload struct {
+	DisplayName *string  `json:"display_name" validate:"omitnil,max=50"`
+	Bio         *string  `json:"bio" validate:"omitnil,max=160"`
+	AvatarURL   *string  `json:"avatar_url" validate:"omitnil,max=2048,eq=|http_url"`
+	Links       []string `json:"links" validate:"omitempty,max=5,dive,max=255,http_url"`
+	Location    *string  `json:"location" validate:"omitnil,max=100"`
+	IsPrivate   *bool    `json:"is_private"`
+}
+
+// GetProfile godoc
+//
+//	@Summary		Get profile
+//	@Description	Get the current user, including their profile and follower, following and post counts
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{object}	userResponse
+//	@Failure		401	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/profile [get]
+func (app *application) getProfileHandler(w http.ResponseWriter, r *http.Request) {
+	user := *app.getUserContext(r)
+
+	stats, err := app.store.Users.ReadStats(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	user.Stats = stats
+
+	if err := app.jsonResponse(w, http.StatusOK, userResponse{Data: user}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// UpdateProfile godoc
+//
+//	@Summary		Update profile
+//	@Description	Update the current user's display name, bio, avatar, links, location and privacy. Making a private account public approves its pending follow requests
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		UpdateProfilePayload	true	"Update profile payload"
+//	@Success		200		{object}	userResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		401		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/profile [patch]
+func (app *application) updateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateProfilePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := *app.getUserContext(r)
+
+	if payload.DisplayName != nil {
+		user.DisplayName = *payload.DisplayName
+	}
+	if payload.Bio != nil {
+		user.Bio = *payload.Bio
+	}
+	if payload.AvatarURL != nil {
+		user.AvatarURL = *payload.AvatarURL
+	}
+	if payload.Links != nil {
+		user.Links = payload.Links
+	}
+	if payload.Location != nil {
+		user.Location = *payload.Location
+	}
+	if payload.IsPrivate != nil {
+		user.IsPrivate = *payload.IsPrivate
+	}
+
+	ctx := r.Context()
+
+	if err := app.store.Users.UpdateProfile(ctx, &user); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.invalidateUserCache(ctx, user.ID)
+
+	if err := app.jsonResponse(w, http.StatusOK, userResponse{Data: user}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
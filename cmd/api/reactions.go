@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type AddReactionPayload struct {
+	Type string `json:"type" validate:"required,oneof=like love laugh wow sad angry" example:"like"`
+}
+
+// AddReaction godoc
+//
+//	@Summary		Add reaction
+//	@Description	React to a post. Each reaction type can be used once per post, but several types can be combined
+//	@Tags			posts
+//	@Accept			json
+//	@Param			id		path	int					true	"Post ID"
+//	@Param			payload	body	AddReactionPayload	true	"Reaction"
+//	@Success		204		"Success"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		409		{object}	errorResponse	"Already reacted with this type"
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/reactions [post]
+func (app *application) addReactionHandler(w http.ResponseWriter, r *http.Request) {
+	var payload AddReactionPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	if err := Validator.Struct(payload); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	post := app.getPostContext(r)
+	user := app.getUserContext(r)
+
+	if err := app.store.Reactions.Add(r.Context(), post.ID, user.ID, payload.Type); err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidReaction):
+			app.badRequest(w, r, err)
+		case errors.Is(err, store.ErrNotFound):
+			app.notFound(w, r)
+		case errors.Is(err, store.ErrAlreadyReacted):
+			app.conflict(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveReaction godoc
+//
+//	@Summary		Remove reaction
+//	@Description	Take back a reaction to a post
+//	@Tags			posts
+//	@Param			id		path	int		true	"Post ID"
+//	@Param			type	path	string	true	"Reaction type"
+//	@Success		204		"Success"
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse	"The post does not exist or has no such reaction from you"
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/reactions/{type} [delete]
+func (app *application) removeReactionHandler(w http.ResponseWriter, r *http.Request) {
+	post := app.getPostContext(r)
+	user := app.getUserContext(r)
+
+	if err := app.store.Reactions.Remove(r.Context(), post.ID, user.ID, chi.URLParam(r, "type")); err != nil {
+		if errors.Is(err, store.ErrNotReacted) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetReactions godoc
+//
+//	@Summary		Get reactions
+//	@Description	List who reacted to a post, most recent first
+//	@Tags			posts
+//	@Produce		json
+//	@Param			id		path		int		true	"Post ID"
+//	@Param			type	query		string	false	"Only list reactions of this type"	Enums(like, love, laugh, wow, sad, angry)
+//	@Param			limit	query		int		false	"Limit"								default(20)
+//	@Param			offset	query		int		false	"Offset"							default(0)
+//	@Success		200		{object}	reactionsResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/reactions [get]
+func (app *application) getReactionsHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := app.readPagination(w, r)
+	if !ok {
+		return
+	}
+
+	reactionType := r.URL.Query().Get("type")
+	if err := Validator.Var(reactionType, "omitempty,oneof=like love laugh wow sad angry"); err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	post := app.getPostContext(r)
+
+	reactions, err := app.store.Reactions.ReadByPostID(r.Context(), post.ID, reactionType, page)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, reactionsResponse{Data: reactions}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// loadPostReactions attaches the post's reaction counts and the viewer's own
+// reactions.
+func (app *application) loadPostReactions(ctx context.Context, post *store.Post, viewerID int64) error {
+	counts, mine, err := app.store.Reactions.ReadSummary(ctx, post.ID, viewerID)
+	if err != nil {
+		return err
+	}
+
+	post.Reactions = counts
+	post.MyReactions = mine
+
+	return nil
+}
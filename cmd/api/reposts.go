@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/andras-szesztai/social/internal/store"
+)
+
+var (
+	errPrivatePost     = errors.New("posts of private accounts cannot be shared")
+	errUnpublishedPost = errors.New("only published posts can be shared")
+)
+
+// Repost godoc
+//
+//	@Summary		Repost
+//	@Description	Share a post with your followers. It shows up in their feeds attributed to its author. Posts of private accounts cannot be reposted
+//	@Tags			posts
+//	@Param			id	path	int	true	"Post ID"
+//	@Success		204	"Success"
+//	@Failure		400	{object}	errorResponse	"The post is not published"
+//	@Failure		403	{object}	errorResponse	"The post belongs to a private account"
+//	@Failure		404	{object}	errorResponse
+//	@Failure		409	{object}	errorResponse	"Already reposted"
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/repost [post]
+func (app *application) repostHandler(w http.ResponseWriter, r *http.Request) {
+	post := app.getPostContext(r)
+	user := app.getUserContext(r)
+
+	ctx := r.Context()
+	if err := app.checkShareable(ctx, post, user.ID); err != nil {
+		switch {
+		case errors.Is(err, errUnpublishedPost):
+			app.badRequest(w, r, err)
+		case errors.Is(err, errPrivatePost):
+			app.forbidden(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.store.Reposts.Create(ctx, post.ID, user.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFound(w, r)
+		case errors.Is(err, store.ErrAlreadyReposted):
+			app.conflict(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UndoRepost godoc
+//
+//	@Summary		Undo repost
+//	@Description	Stop sharing a post you reposted
+//	@Tags			posts
+//	@Param			id	path	int	true	"Post ID"
+//	@Success		204	"Success"
+//	@Failure		400	{object}	errorResponse
+//	@Failure		404	{object}	errorResponse	"The post does not exist or is not reposted"
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/repost [delete]
+func (app *application) undoRepostHandler(w http.ResponseWriter, r *http.Request) {
+	post := app.getPostContext(r)
+	user := app.getUserContext(r)
+
+	if err := app.store.Reposts.Delete(r.Context(), post.ID, user.ID); err != nil {
+		if errors.Is(err, store.ErrNotReposted) {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkShareable returns errUnpublishedPost for drafts and scheduled posts,
+// and errPrivatePost unless the post's author is public or the user sharing
+// it. Reposts and quotes would otherwise show a private post to people who
+// are not approved followers.
+func (app *application) checkShareable(ctx context.Context, post *store.Post, userID int64) error {
+	if post.Status != store.PostStatusPublished {
+		return errUnpublishedPost
+	}
+	if post.UserID == userID {
+		return nil
+	}
+
+	author, err := app.getUser(ctx, post.UserID)
+	if err != nil {
+		return err
+	}
+	if author.IsPrivate {
+		return errPrivatePost
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+var errPostChanged = errors.New("post was changed in the meantime, please retry")
+
+// GetPostRevisions godoc
+//
+//	@Summary		Get post revisions
+//	@Description	List the earlier versions of a post, most recent first. Only the author and moderators see versions from before the post was published
+//	@Tags			posts
+//	@Produce		json
+//	@Param			id		path		int	true	"Post ID"
+//	@Param			limit	query		int	false	"Limit"		default(20)
+//	@Param			offset	query		int	false	"Offset"	default(0)
+//	@Success		200		{object}	postRevisionsResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/revisions [get]
+func (app *application) getPostRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := app.readPagination(w, r)
+	if !ok {
+		return
+	}
+
+	post := app.getPostContext(r)
+
+	publishedOnly, err := app.revisionsPublishedOnly(r)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	revisions, err := app.store.Posts.ReadRevisions(r.Context(), post.ID, publishedOnly, page)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, postRevisionsResponse{Data: revisions}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetPostRevision godoc
+//
+//	@Summary		Get post revision
+//	@Description	Get an earlier version of a post. Only the author and moderators see versions from before the post was published
+//	@Tags			posts
+//	@Produce		json
+//	@Param			id		path		int	true	"Post ID"
+//	@Param			version	path		int	true	"Version"
+//	@Success		200		{object}	postRevisionResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/revisions/{version} [get]
+func (app *application) getPostRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	revision, ok := app.readPostRevision(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, postRevisionResponse{Data: *revision}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// RestorePostRevision godoc
+//
+//	@Summary		Restore post revision
+//	@Description	Bring back the title, content and tags of an earlier version. The current version is kept as a revision in turn
+//	@Tags			posts
+//	@Produce		json
+//	@Param			id		path		int	true	"Post ID"
+//	@Param			version	path		int	true	"Version"
+//	@Success		200		{object}	postResponse
+//	@Failure		400		{object}	errorResponse
+//	@Failure		403		{object}	errorResponse
+//	@Failure		404		{object}	errorResponse
+//	@Failure		409		{object}	errorResponse	"The post was changed in the meantime"
+//	@Failure		500		{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/revisions/{version}/restore [post]
+func (app *application) restorePostRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	revision, ok := app.readPostRevision(w, r)
+	if !ok {
+		return
+	}
+
+	post := app.getPostContext(r)
+
+	postToUpdate := store.Post{
+		ID:        post.ID,
+		Title:     revision.Title,
+		Content:   revision.Content,
+		Tags:      revision.Tags,
+		Version:   post.Version,
+		Status:    post.Status,
+		PublishAt: post.PublishAt,
+	}
+
+	updatedPost, err := app.store.Posts.Update(r.Context(), &postToUpdate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.conflict(w, r, errPostChanged)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, postResponse{Data: *updatedPost}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// readPostRevision loads the revision named by the {version} URL parameter
+// of the post in the request context.
+func (app *application) readPostRevision(w http.ResponseWriter, r *http.Request) (*store.PostRevision, bool) {
+	version, err := strconv.ParseInt(chi.URLParam(r, "version"), 10, 64)
+	if err != nil {
+		app.badRequest(w, r, err)
+		return nil, false
+	}
+
+	post := app.getPostContext(r)
+
+	publishedOnly, err := app.revisionsPublishedOnly(r)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return nil, false
+	}
+
+	revision, err := app.store.Posts.ReadRevision(r.Context(), post.ID, publishedOnly, version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.notFound(w, r)
+			return nil, false
+		}
+		app.internalServerError(w, r, err)
+		return nil, false
+	}
+
+	return revision, true
+}
+
+// revisionsPublishedOnly reports whether the caller may only see revisions
+// saved after the post was published. The author and moderators, who may
+// edit the post, see its whole history.
+func (app *application) revisionsPublishedOnly(r *http.Request) (bool, error) {
+	user := app.getUserContext(r)
+	post := app.getPostContext(r)
+
+	if user.ID == post.UserID {
+		return false, nil
+	}
+
+	allowed, err := app.checkRolePrecedence(r.Context(), user.Role.Level, "moderator")
+	if err != nil {
+		return false, err
+	}
+
+	return !allowed, nil
+}
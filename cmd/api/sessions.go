@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/mailer"
+	"github.com/andras-szesztai/social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// GetSessions godoc
+//
+//	@Summary		List sessions
+//	@Description	List the devices the current user is signed in on
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{object}	sessionsResponse
+//	@Failure		401	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/sessions [get]
+func (app *application) getSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.getUserContext(r)
+	currentID, _ := app.getTokenClaimsContext(r)["sid"].(string)
+
+	sessions, err := app.store.Sessions.ReadByUserID(r.Context(), user.ID, app.config.auth.token.refreshExp)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, sessionsResponse{Data: sessions}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// RevokeSession godoc
+//
+//	@Summary		Revoke session
+//	@Description	Sign the current user out on one device
+//	@Tags			users
+//	@Produce		json
+//	@Param			sessionID	path	string	true	"Session ID"
+//	@Success		204			"Session revoked"
+//	@Failure		400			{object}	errorResponse
+//	@Failure		401			{object}	errorResponse
+//	@Failure		404			{object}	errorResponse
+//	@Failure		500			{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/sessions/{sessionID} [delete]
+func (app *application) revokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		app.badRequest(w, r, err)
+		return
+	}
+
+	user := app.getUserContext(r)
+
+	err = app.revokeSession(r.Context(), sessionID.String(), user.ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			app.notFound(w, r)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// startSession records a login from the requesting device and emails the
+// user when it is a device they have not signed in with before.
+func (app *application) startSession(r *http.Request, user *store.User) (*store.Session, error) {
+	ctx := r.Context()
+
+	session := &store.Session{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		UserAgent: r.UserAgent(),
+		IP:        clientIP(r),
+	}
+
+	known, err := app.store.Sessions.IsKnownDevice(ctx, user.ID, session.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.store.Sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	if !known {
+		err := app.mailer.Send(mailer.NewDeviceLoginTemplate, user.Username, user.Email, map[string]any{
+			"Username":    user.Username,
+			"UserAgent":   session.UserAgent,
+			"IP":          session.IP,
+			"Time":        session.CreatedAt.UTC().Format(time.RFC1123),
+			"SessionsURL": fmt.Sprintf("%s/settings/sessions", app.config.frontendURL),
+		}, app.config.env == "production")
+		if err != nil {
+			app.logger.Errorw("failed to send new device login email", "error", err)
+		}
+	}
+
+	return session, nil
+}
+
+// revokeSession ends the session and its refresh token family. The auth
+// middleware rejects access tokens already issued for it; with Redis enabled
+// the revocation is cached for the lifetime of those tokens.
+func (app *application) revokeSession(ctx context.Context, sessionID string, userID int64) error {
+	if err := app.store.Sessions.Revoke(ctx, sessionID, userID); err != nil {
+		return err
+	}
+
+	if app.config.redis.enabled {
+		return app.cache.Tokens.RevokeSession(ctx, sessionID, app.config.auth.token.exp)
+	}
+
+	return nil
+}
+
+func (app *application) isSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	if !app.config.redis.enabled {
+		return app.store.Sessions.IsRevoked(ctx, sessionID)
+	}
+
+	return app.cache.Tokens.IsSessionRevoked(ctx, sessionID)
+}
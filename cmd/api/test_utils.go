@@ -1,26 +1,88 @@
 package main
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/andras-szesztai/social/internal/auth"
+	"github.com/andras-szesztai/social/internal/storage"
 	"github.com/andras-szesztai/social/internal/store"
 	"github.com/andras-szesztai/social/internal/store/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 )
 
-func newTestApplication(t *testing.T) *application {
+type testAppOption func(*application)
+
+// withUserStore replaces the mock user store, so a test can configure it.
+func withUserStore(users *store.MockUserStore) testAppOption {
+	return func(app *application) {
+		app.store.Users = users
+	}
+}
+
+// withCachedUsers lets the mock token pass authentication and serves the
+// given users from the cache. Any other user is a cache miss.
+func withCachedUsers(users ...*store.User) testAppOption {
+	return func(app *application) {
+		tokens := app.cache.Tokens.(*cache.MockTokenCache)
+		tokens.On("IsRevoked", mock.Anything).Return(false, nil)
+		tokens.On("GetGeneration", mock.Anything).Return(int64(0), nil)
+
+		userCache := app.cache.Users.(*cache.MockUserCache)
+		for _, user := range users {
+			userCache.On("Get", user.ID).Return(user, nil)
+		}
+		userCache.On("Get", mock.Anything).Return((*store.User)(nil), redis.Nil)
+	}
+}
+
+func newTestApplication(t *testing.T, opts ...testAppOption) *application {
 	t.Helper()
 
-	return &application{
-		logger:        zap.NewNop().Sugar(),
-		store:         store.NewMockStore(),
-		cache:         cache.NewMockCache(),
-		authenticator: auth.NewMockAuth(),
+	passwordPolicy, err := auth.NewPasswordPolicy(8, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	localStorage, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/v1/media/files", "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &application{
+		logger:         zap.NewNop().Sugar(),
+		store:          store.NewMockStore(),
+		cache:          cache.NewMockCache(),
+		authenticator:  auth.NewMockAuth(),
+		passwordPolicy: passwordPolicy,
+		storage:        localStorage,
 		config: config{
 			redis: redisConfig{
 				enabled: true,
 			},
 		},
 	}
+
+	for _, opt := range opts {
+		opt(app)
+	}
+
+	return app
+}
+
+func executeRequest(req *http.Request, mux http.Handler) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	return rr
+}
+
+func checkResponseCode(t *testing.T, expected, actual int) {
+	t.Helper()
+
+	if expected != actual {
+		t.Errorf("expected response code %d, got %d", expected, actual)
+	}
 }
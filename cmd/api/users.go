@@ -2,9 +2,9 @@ package main
 
 import (
 	"context"
-	"crypto/sha256"
 	"database/sql"
-	"encoding/hex"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -16,19 +16,38 @@ import (
 // GetUser godoc
 //
 //	@Summary		Get user
-//	@Description	Get user by id
+//	@Description	Get the public profile of a user by id with follower, following and post counts, and how they relate to the caller
 //	@Tags			users
 //	@Produce		json
 //	@Param			id	path		int	true	"User ID"
-//	@Success		200	{object}	userResponse
+//	@Success		200	{object}	publicUserResponse
 //	@Failure		400	{object}	errorResponse
 //	@Failure		404	{object}	errorResponse
 //	@Failure		500	{object}	errorResponse
 //	@Security		ApiKeyAuth
 //	@Router			/users/{id} [get]
 func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
-	user := app.getUserContext(r)
-	if err := app.jsonResponse(w, http.StatusOK, userResponse{Data: *user}); err != nil {
+	target := app.getTargetUserContext(r)
+	ctx := r.Context()
+
+	stats, err := app.store.Users.ReadStats(ctx, target.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	user := target.Public()
+	user.Stats = stats
+
+	if caller := app.getUserContext(r); caller != nil && caller.ID != target.ID {
+		user.Relationship, err = app.store.Users.ReadRelationship(ctx, target.ID, caller.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, publicUserResponse{Data: user}); err != nil {
 		app.internalServerError(w, r, err)
 	}
 }
@@ -36,65 +55,71 @@ func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
 // FollowUser godoc
 //
 //	@Summary		Follow user
-//	@Description	Follow a user by their ID
+//	@Description	Follow a user by their ID. Following a private account sends a follow request the user has to approve
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path	int	true	"User ID"
+//	@Success		202	"Follow request sent"
 //	@Success		204	"Success"
-//	@Failure		400	{object}	errorResponse
+//	@Failure		400	{object}	errorResponse	"Users cannot follow themselves"
+//	@Failure		403	{object}	errorResponse	"One of the users blocked the other"
 //	@Failure		404	{object}	errorResponse
+//	@Failure		409	{object}	errorResponse	"Already following the user or a follow request is pending"
 //	@Failure		500	{object}	errorResponse
 //	@Security		ApiKeyAuth
 //	@Router			/users/{id}/follow [post]
 func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request) {
-	followerUser := app.getUserContext(r)
-	followedId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
-	if err != nil {
-		app.badRequest(w, r, err)
-		return
-	}
+	follower := app.getUserContext(r)
+	followed := app.getTargetUserContext(r)
 
 	ctx := r.Context()
-	if err := app.store.Users.Follow(ctx, followedId, followerUser.ID); err != nil {
-		if err == sql.ErrNoRows {
+	pending, err := app.store.Users.Follow(ctx, followed.ID, follower.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrSelfFollow):
+			app.badRequest(w, r, err)
+		case errors.Is(err, store.ErrNotFound):
 			app.notFound(w, r)
-			return
+		case errors.Is(err, store.ErrAlreadyFollowing), errors.Is(err, store.ErrFollowRequestPending):
+			app.conflict(w, r, err)
+		case errors.Is(err, store.ErrBlocked):
+			app.forbidden(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
 		}
-		app.internalServerError(w, r, err)
 		return
 	}
 
-	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
-		app.internalServerError(w, r, err)
+	if pending {
+		w.WriteHeader(http.StatusAccepted)
+		return
 	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // UnfollowUser godoc
 //
 //	@Summary		Unfollow user
-//	@Description	Unfollow a user by their ID
+//	@Description	Unfollow a user by their ID, or withdraw a pending follow request
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path	int	true	"User ID"
 //	@Success		204	"Success"
 //	@Failure		400	{object}	errorResponse
-//	@Failure		404	{object}	errorResponse
+//	@Failure		404	{object}	errorResponse	"The user does not exist or is not followed or requested"
 //	@Failure		500	{object}	errorResponse
 //	@Security		ApiKeyAuth
 //	@Router			/users/{id}/unfollow [post]
 func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Request) {
-	user := app.getUserContext(r)
-	followedId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
-	if err != nil {
-		app.badRequest(w, r, err)
-		return
-	}
+	follower := app.getUserContext(r)
+	followed := app.getTargetUserContext(r)
 
 	ctx := r.Context()
-	if err := app.store.Users.Unfollow(ctx, followedId, user.ID); err != nil {
-		if err == sql.ErrNoRows {
+	if err := app.store.Users.Unfollow(ctx, followed.ID, follower.ID); err != nil {
+		if errors.Is(err, store.ErrNotFollowing) {
 			app.notFound(w, r)
 			return
 		}
@@ -102,9 +127,7 @@ func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
-		app.internalServerError(w, r, err)
-	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // GetUserFeed godoc
@@ -172,21 +195,21 @@ func (app *application) getUserFeedHandler(w http.ResponseWriter, r *http.Reques
 //	@Security		ApiKeyAuth
 //	@Router			/users/{id}/activate/{token} [put]
 func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Request) {
-	user := app.getUserContext(r)
+	user := app.getTargetUserContext(r)
 	token := chi.URLParam(r, "token")
 
 	ctx := r.Context()
 
-	hash := sha256.Sum256([]byte(token))
-	hashToken := hex.EncodeToString(hash[:])
-
-	err := app.store.Users.Activate(ctx, user.ID, hashToken)
+	err := app.store.Users.Activate(ctx, user.ID, hashToken(token))
 	if err != nil {
-		if err == store.ErrNotFound {
+		switch err {
+		case store.ErrNotFound, sql.ErrNoRows:
 			app.notFound(w, r)
-			return
+		case store.ErrInvitationExpired:
+			app.badRequest(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
 		}
-		app.internalServerError(w, r, err)
 		return
 	}
 
@@ -198,33 +221,82 @@ func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Reque
 // DeleteUser godoc
 //
 //	@Summary		Delete user
-//	@Description	Delete a user by their ID
+//	@Description	Deactivate a user by their ID. Only the user or an admin may do this. The account is hidden and signed out everywhere, and purged once the restore window has passed unless the user signs in again before then.
 //	@Tags			users
 //	@Produce		json
 //	@Param			id	path	int	true	"User ID"
-//	@Success		204	"User deleted"
+//	@Success		204	"User deactivated"
 //	@Failure		400	{object}	errorResponse
+//	@Failure		403	{object}	errorResponse
 //	@Failure		404	{object}	errorResponse
 //	@Failure		500	{object}	errorResponse
 //	@Security		ApiKeyAuth
 //	@Router			/users/{id} [delete]
 func (app *application) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
-	user := app.getUserContext(r)
+	user := app.getTargetUserContext(r)
+
+	if caller := app.getUserContext(r); caller.ID != user.ID {
+		allowed, err := app.checkRolePrecedence(r.Context(), caller.Role.Level, "admin")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbidden(w, r, fmt.Errorf("forbidden"))
+			return
+		}
+	}
+
+	app.deactivateUser(w, r, user.ID)
+}
+
+// DeleteAccount godoc
+//
+//	@Summary		Delete account
+//	@Description	Deactivate the authenticated user's account. It is hidden and signed out everywhere, and purged once the restore window has passed unless the user signs in again before then.
+//	@Tags			users
+//	@Produce		json
+//	@Success		204	"Account deactivated"
+//	@Failure		401	{object}	errorResponse
+//	@Failure		403	{object}	errorResponse
+//	@Failure		500	{object}	errorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/users/me [delete]
+func (app *application) deleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	app.deactivateUser(w, r, app.getUserContext(r).ID)
+}
 
+// deactivateUser schedules the account for purging and ends its sessions.
+func (app *application) deactivateUser(w http.ResponseWriter, r *http.Request, userID int64) {
 	ctx := r.Context()
 
-	err := app.store.Users.Delete(ctx, user.ID)
-	if err != nil {
+	if _, err := app.store.Users.Deactivate(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.notFound(w, r)
+			return
+		}
 		app.internalServerError(w, r, err)
+		return
 	}
 
-	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+	if err := app.revokeAllSessions(ctx, userID); err != nil {
 		app.internalServerError(w, r, err)
+		return
 	}
+
+	app.invalidateUserCache(ctx, userID)
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
-const userContextKey = contextKey("user")
+const (
+	userContextKey       = contextKey("user")
+	targetUserContextKey = contextKey("targetUser")
+)
 
+// usersContextMiddleware loads the user addressed by the {id} URL parameter.
+// It is kept apart from the authenticated user, which AuthTokenMiddleware
+// stores under userContextKey.
 func (app *application) usersContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -244,7 +316,12 @@ func (app *application) usersContextMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), userContextKey, user)
+		if user.DeactivatedAt != nil {
+			app.notFound(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), targetUserContextKey, user)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -253,3 +330,8 @@ func (app *application) getUserContext(r *http.Request) *store.User {
 	user, _ := r.Context().Value(userContextKey).(*store.User)
 	return user
 }
+
+func (app *application) getTargetUserContext(r *http.Request) *store.User {
+	user, _ := r.Context().Value(targetUserContextKey).(*store.User)
+	return user
+}
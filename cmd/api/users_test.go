@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andras-szesztai/social/internal/store"
+)
+
+// followTestUsers caches user 1, whom the mock token authenticates, and
+// user 2 to follow.
+var followTestUsers = []*store.User{
+	{ID: 1, Role: &store.Role{Name: "user"}},
+	{ID: 2, Role: &store.Role{Name: "user"}},
+}
+
+func newAuthenticatedRequest(t *testing.T, app *application, method, path string) *http.Request {
+	t.Helper()
+
+	token, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func readUnknownUser(ctx context.Context, id int64) (*store.User, error) {
+	return nil, sql.ErrNoRows
+}
+
+func TestFollowUser(t *testing.T) {
+	t.Run("should follow another user", func(t *testing.T) {
+		app := newTestApplication(t, withCachedUsers(followTestUsers...))
+
+		rr := executeRequest(newAuthenticatedRequest(t, app, http.MethodPost, "/v1/users/2/follow"), app.mountRoutes())
+		checkResponseCode(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("should request to follow a private account", func(t *testing.T) {
+		users := &store.MockUserStore{
+			FollowFunc: func(ctx context.Context, userID, followerID int64) (bool, error) {
+				return true, nil
+			},
+		}
+		app := newTestApplication(t, withUserStore(users), withCachedUsers(followTestUsers...))
+
+		rr := executeRequest(newAuthenticatedRequest(t, app, http.MethodPost, "/v1/users/2/follow"), app.mountRoutes())
+		checkResponseCode(t, http.StatusAccepted, rr.Code)
+	})
+
+	t.Run("should reject following yourself", func(t *testing.T) {
+		users := &store.MockUserStore{
+			FollowFunc: func(ctx context.Context, userID, followerID int64) (bool, error) {
+				return false, store.ErrSelfFollow
+			},
+		}
+		app := newTestApplication(t, withUserStore(users), withCachedUsers(followTestUsers...))
+
+		rr := executeRequest(newAuthenticatedRequest(t, app, http.MethodPost, "/v1/users/1/follow"), app.mountRoutes())
+		checkResponseCode(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should not find an unknown user", func(t *testing.T) {
+		users := &store.MockUserStore{ReadByIDFunc: readUnknownUser}
+		app := newTestApplication(t, withUserStore(users), withCachedUsers(followTestUsers...))
+
+		rr := executeRequest(newAuthenticatedRequest(t, app, http.MethodPost, "/v1/users/404/follow"), app.mountRoutes())
+		checkResponseCode(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("should conflict when already following", func(t *testing.T) {
+		users := &store.MockUserStore{
+			FollowFunc: func(ctx context.Context, userID, followerID int64) (bool, error) {
+				return false, store.ErrAlreadyFollowing
+			},
+		}
+		app := newTestApplication(t, withUserStore(users), withCachedUsers(followTestUsers...))
+
+		rr := executeRequest(newAuthenticatedRequest(t, app, http.MethodPost, "/v1/users/2/follow"), app.mountRoutes())
+		checkResponseCode(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("should require authentication", func(t *testing.T) {
+		app := newTestApplication(t, withCachedUsers(followTestUsers...))
+
+		rr := executeRequest(httptest.NewRequest(http.MethodPost, "/v1/users/2/follow", nil), app.mountRoutes())
+		checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestUnfollowUser(t *testing.T) {
+	t.Run("should unfollow a followed user", func(t *testing.T) {
+		app := newTestApplication(t, withCachedUsers(followTestUsers...))
+
+		rr := executeRequest(newAuthenticatedRequest(t, app, http.MethodPost, "/v1/users/2/unfollow"), app.mountRoutes())
+		checkResponseCode(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("should not find a user that is not followed", func(t *testing.T) {
+		users := &store.MockUserStore{
+			UnfollowFunc: func(ctx context.Context, userID, followerID int64) error {
+				return store.ErrNotFollowing
+			},
+		}
+		app := newTestApplication(t, withUserStore(users), withCachedUsers(followTestUsers...))
+
+		rr := executeRequest(newAuthenticatedRequest(t, app, http.MethodPost, "/v1/users/2/unfollow"), app.mountRoutes())
+		checkResponseCode(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("should not find an unknown user", func(t *testing.T) {
+		users := &store.MockUserStore{ReadByIDFunc: readUnknownUser}
+		app := newTestApplication(t, withUserStore(users), withCachedUsers(followTestUsers...))
+
+		rr := executeRequest(newAuthenticatedRequest(t, app, http.MethodPost, "/v1/users/404/unfollow"), app.mountRoutes())
+		checkResponseCode(t, http.StatusNotFound, rr.Code)
+	})
+}
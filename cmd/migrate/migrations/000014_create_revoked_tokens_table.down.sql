@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN token_generation;
+
+DROP TABLE IF EXISTS revoked_tokens;
@@ -0,0 +1,4 @@
+ALTER TABLE refresh_tokens DROP COLUMN mfa_verified;
+
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_totp;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS login_failures (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(16) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP(0) WITH TIME ZONE,
+    last_failure_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_login_failure UNIQUE (scope, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_failures_locked_until ON login_failures (locked_until);
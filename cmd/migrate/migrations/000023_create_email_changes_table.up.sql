@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS email_changes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    old_email CITEXT NOT NULL,
+    new_email CITEXT NOT NULL,
+    token bytea NOT NULL UNIQUE,
+    expires_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    confirmed_at TIMESTAMP(0) WITH TIME ZONE,
+    revert_token bytea UNIQUE,
+    revert_expires_at TIMESTAMP(0) WITH TIME ZONE,
+    reverted_at TIMESTAMP(0) WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_changes_user_id ON email_changes (user_id);
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    DROP COLUMN location,
+    DROP COLUMN links,
+    DROP COLUMN avatar_url,
+    DROP COLUMN bio,
+    DROP COLUMN display_name;
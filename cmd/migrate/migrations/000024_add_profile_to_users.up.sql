@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN display_name VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN bio VARCHAR(160) NOT NULL DEFAULT '',
+    ADD COLUMN avatar_url VARCHAR(2048) NOT NULL DEFAULT '',
+    ADD COLUMN links TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN location VARCHAR(100) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE followers
+DROP CONSTRAINT IF EXISTS no_self_follow;
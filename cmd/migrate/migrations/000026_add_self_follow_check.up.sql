@@ -0,0 +1,4 @@
+DELETE FROM followers WHERE user_id = follower_id;
+
+ALTER TABLE followers
+ADD CONSTRAINT no_self_follow CHECK (user_id <> follower_id);
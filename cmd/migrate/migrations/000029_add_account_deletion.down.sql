@@ -0,0 +1,15 @@
+ALTER TABLE comments
+DROP CONSTRAINT IF EXISTS comments_post_id_fkey,
+ADD CONSTRAINT comments_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts(id),
+DROP CONSTRAINT IF EXISTS comments_user_id_fkey,
+ADD CONSTRAINT comments_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE posts
+DROP CONSTRAINT IF EXISTS posts_user_id_fkey,
+ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+
+DROP INDEX IF EXISTS idx_users_deactivated_at;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS anonymized_at,
+DROP COLUMN IF EXISTS deactivated_at;
@@ -0,0 +1,16 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMP(0) WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP(0) WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_deactivated_at ON users (deactivated_at) WHERE deactivated_at IS NOT NULL AND anonymized_at IS NULL;
+
+-- Let purging with the cascade policy remove a user's posts and comments.
+ALTER TABLE posts
+DROP CONSTRAINT IF EXISTS posts_user_id_fkey,
+ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE comments
+DROP CONSTRAINT IF EXISTS comments_user_id_fkey,
+ADD CONSTRAINT comments_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+DROP CONSTRAINT IF EXISTS comments_post_id_fkey,
+ADD CONSTRAINT comments_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_posts_publish_at;
+
+ALTER TABLE posts
+DROP CONSTRAINT IF EXISTS published_post_published_at,
+DROP CONSTRAINT IF EXISTS scheduled_post_publish_at,
+DROP CONSTRAINT IF EXISTS valid_post_status,
+DROP COLUMN IF EXISTS published_at,
+DROP COLUMN IF EXISTS publish_at,
+DROP COLUMN IF EXISTS status;
@@ -0,0 +1,13 @@
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'published',
+ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP(0) WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS published_at TIMESTAMP(0) WITH TIME ZONE;
+
+UPDATE posts SET published_at = created_at WHERE published_at IS NULL;
+
+ALTER TABLE posts
+ADD CONSTRAINT valid_post_status CHECK (status IN ('draft', 'scheduled', 'published')),
+ADD CONSTRAINT scheduled_post_publish_at CHECK (status <> 'scheduled' OR publish_at IS NOT NULL),
+ADD CONSTRAINT published_post_published_at CHECK (status <> 'published' OR published_at IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_posts_publish_at ON posts (publish_at) WHERE status = 'scheduled';
@@ -0,0 +1,7 @@
+ALTER TABLE users
+    ADD COLUMN avatar_media_id BIGINT REFERENCES media(id) ON DELETE SET NULL;
+
+UPDATE users u
+SET avatar_media_id = m.id
+FROM media m
+WHERE m.user_id = u.id AND u.avatar_url LIKE '%/' || m.id || '/content%';
@@ -6,6 +6,7 @@ import (
 	"github.com/andras-szesztai/social/internal/db"
 	"github.com/andras-szesztai/social/internal/env"
 	"github.com/andras-szesztai/social/internal/store"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func main() {
@@ -17,7 +18,7 @@ func main() {
 	log.Println("database connection pool established")
 	defer conn.Close()
 
-	store := store.NewStore(conn)
+	store := store.NewStore(conn, store.BcryptHasher{Cost: bcrypt.DefaultCost})
 	err = db.Seed(store, conn)
 	if err != nil {
 		log.Fatal(err)
@@ -7,19 +7,25 @@ import (
 )
 
 type JWTAuthenticator struct {
-	secret string
-	aud    string
-	iss    string
+	keys *KeySet
+	aud  string
+	iss  string
 }
 
-func NewJWTAuthenticator(secret, aud, iss string) *JWTAuthenticator {
-	return &JWTAuthenticator{secret: secret, aud: aud, iss: iss}
+func NewJWTAuthenticator(keys *KeySet, aud, iss string) *JWTAuthenticator {
+	return &JWTAuthenticator{keys: keys, aud: aud, iss: iss}
 }
 
 func (a *JWTAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	key, err := a.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
 
-	tokenString, err := token.SignedString([]byte(a.secret))
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	tokenString, err := token.SignedString(key.signKey)
 	if err != nil {
 		return "", err
 	}
@@ -29,15 +35,32 @@ func (a *JWTAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
 
 func (a *JWTAuthenticator) ValidateToken(token string) (*jwt.Token, error) {
 	return jwt.Parse(token, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		var key *Key
+		var err error
+
+		kid, ok := token.Header["kid"].(string)
+		if ok {
+			key, err = a.keys.VerificationKey(kid)
+		} else {
+			// Tokens issued before key ids were introduced.
+			key, err = a.keys.SigningKey()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
-		return []byte(a.secret), nil
+		return key.verifyKey, nil
 	},
-		jwt.WithValidMethods([]string{"HS256"}),
 		jwt.WithAudience(a.aud),
 		jwt.WithIssuer(a.iss),
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		jwt.WithValidMethods(a.keys.methods()),
 	)
 }
+
+func (a *JWTAuthenticator) JWKS() JWKS {
+	return a.keys.JWKS()
+}
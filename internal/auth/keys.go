@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a single signing key. A key signs new tokens from ActiveFrom until
+// the next key in the set becomes active, and keeps verifying tokens until
+// RetireAt, or for the key set's grace period after it stopped signing when
+// RetireAt is not set.
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	ActiveFrom time.Time
+	RetireAt   time.Time
+	signKey    any
+	verifyKey  any
+}
+
+type KeySet struct {
+	keys  []*Key
+	grace time.Duration
+	now   func() time.Time
+}
+
+// NewKeySet orders keys by activation time. grace is how long a key keeps
+// verifying tokens after its successor took over signing.
+func NewKeySet(keys []*Key, grace time.Duration) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one signing key is required")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, fmt.Errorf("signing key id is required")
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate signing key id %q", key.ID)
+		}
+		seen[key.ID] = true
+	}
+
+	sorted := make([]*Key, len(keys))
+	copy(sorted, keys)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActiveFrom.Before(sorted[j].ActiveFrom)
+	})
+
+	return &KeySet{keys: sorted, grace: grace, now: time.Now}, nil
+}
+
+// NewHMACKeySet is the single shared secret HS256 setup used when no key
+// file is configured.
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{
+		keys: []*Key{{
+			ID:        "default",
+			Method:    jwt.SigningMethodHS256,
+			signKey:   []byte(secret),
+			verifyKey: []byte(secret),
+		}},
+		now: time.Now,
+	}
+}
+
+// SigningKey returns the most recently activated key that is not retired.
+func (ks *KeySet) SigningKey() (*Key, error) {
+	now := ks.now()
+
+	var current *Key
+	for _, key := range ks.keys {
+		if key.ActiveFrom.After(now) {
+			break
+		}
+		if !key.RetireAt.IsZero() && !now.Before(key.RetireAt) {
+			continue
+		}
+		current = key
+	}
+
+	if current == nil {
+		return nil, fmt.Errorf("no active signing key")
+	}
+
+	return current, nil
+}
+
+// VerificationKey returns the key with the given id as long as it is still
+// allowed to verify tokens. Keys scheduled for the future verify as well so
+// they can be published before they start signing.
+func (ks *KeySet) VerificationKey(kid string) (*Key, error) {
+	now := ks.now()
+
+	for i, key := range ks.keys {
+		if key.ID != kid {
+			continue
+		}
+
+		retireAt := key.RetireAt
+		if retireAt.IsZero() && i+1 < len(ks.keys) {
+			retireAt = ks.keys[i+1].ActiveFrom.Add(ks.grace)
+		}
+		if !retireAt.IsZero() && !now.Before(retireAt) {
+			return nil, fmt.Errorf("signing key %q is retired", kid)
+		}
+
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (ks *KeySet) methods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range ks.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+
+	return methods
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public part of every asymmetric key that can still verify
+// tokens. Shared HMAC secrets are never published.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range ks.keys {
+		if _, err := ks.VerificationKey(key.ID); err != nil {
+			continue
+		}
+
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+type keyFileEntry struct {
+	ID             string    `json:"kid"`
+	Alg            string    `json:"alg"`
+	PrivateKeyFile string    `json:"private_key_file"`
+	Secret         string    `json:"secret"`
+	ActiveFrom     time.Time `json:"active_from"`
+	RetireAt       time.Time `json:"retire_at"`
+}
+
+type keyFile struct {
+	Keys []keyFileEntry `json:"keys"`
+}
+
+// LoadKeySet reads a JSON key manifest describing the rotation schedule, e.g.
+//
+//	{"keys": [{"kid": "2025-06", "alg": "RS256", "private_key_file": "2025-06.pem", "active_from": "2025-06-01T00:00:00Z"}]}
+//
+// Relative key file paths are resolved against the manifest's directory.
+func LoadKeySet(path string, grace time.Duration) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+
+	keys := make([]*Key, 0, len(file.Keys))
+	for _, entry := range file.Keys {
+		key, err := loadKey(filepath.Dir(path), entry)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %q: %w", entry.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(keys, grace)
+}
+
+func loadKey(dir string, entry keyFileEntry) (*Key, error) {
+	key := &Key{
+		ID:         entry.ID,
+		ActiveFrom: entry.ActiveFrom,
+		RetireAt:   entry.RetireAt,
+	}
+
+	if entry.Alg == jwt.SigningMethodHS256.Alg() {
+		if entry.Secret == "" {
+			return nil, fmt.Errorf("secret is required for %s", entry.Alg)
+		}
+		key.Method = jwt.SigningMethodHS256
+		key.signKey = []byte(entry.Secret)
+		key.verifyKey = []byte(entry.Secret)
+		return key, nil
+	}
+
+	keyPath := entry.PrivateKeyFile
+	if !filepath.IsAbs(keyPath) {
+		keyPath = filepath.Join(dir, keyPath)
+	}
+
+	pem, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	switch entry.Alg {
+	case jwt.SigningMethodRS256.Alg():
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.signKey = private
+		key.verifyKey = &private.PublicKey
+	case jwt.SigningMethodEdDSA.Alg():
+		private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		edPrivate, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("not an Ed25519 private key")
+		}
+		key.Method = jwt.SigningMethodEdDSA
+		key.signKey = edPrivate
+		key.verifyKey = edPrivate.Public()
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", entry.Alg)
+	}
+
+	return key, nil
+}
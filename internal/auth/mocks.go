@@ -16,6 +16,9 @@ var testClaims = jwt.MapClaims{
 	"aud": "test-aud",
 	"iss": "test-iss",
 	"sub": 1,
+	"jti": "00000000-0000-0000-0000-000000000001",
+	"gen": 0,
+	"mfa": false,
 	"exp": time.Now().Add(time.Hour * 24).Unix(),
 	"iat": time.Now().Unix(),
 	"nbf": time.Now().Unix(),
@@ -32,3 +35,7 @@ func (m *MockAuth) ValidateToken(token string) (*jwt.Token, error) {
 		return []byte("test-key"), nil
 	})
 }
+
+func (m *MockAuth) JWKS() JWKS {
+	return JWKS{Keys: []JWK{}}
+}
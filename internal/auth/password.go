@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrPasswordTooShort = errors.New("password is too short")
+	ErrPasswordTooLong  = errors.New("password is too long")
+	ErrPasswordBlocked  = errors.New("password is too common or has appeared in a data breach")
+	ErrPasswordPersonal = errors.New("password must not contain your username or email")
+)
+
+// maxPasswordBytes is the most bcrypt takes into account.
+const maxPasswordBytes = 72
+
+type PasswordPolicy struct {
+	MinLength int
+	blocklist map[string]struct{}
+}
+
+// NewPasswordPolicy creates a policy requiring minLength characters. When
+// blocklistPath is set, passwords listed in the file, one per line and
+// compared case-insensitively, are rejected.
+func NewPasswordPolicy(minLength int, blocklistPath string) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{MinLength: minLength, blocklist: map[string]struct{}{}}
+	if blocklistPath == "" {
+		return policy, nil
+	}
+
+	file, err := os.Open(blocklistPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open password blocklist: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		policy.blocklist[line] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read password blocklist: %w", err)
+	}
+
+	return policy, nil
+}
+
+// Validate checks the password against the policy. personal holds values
+// such as the username and email the password must not contain.
+func (p *PasswordPolicy) Validate(password string, personal ...string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: at least %d characters are required", ErrPasswordTooShort, p.MinLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return ErrPasswordTooLong
+	}
+
+	lower := strings.ToLower(password)
+	if _, ok := p.blocklist[lower]; ok {
+		return ErrPasswordBlocked
+	}
+
+	for _, value := range personal {
+		value = strings.ToLower(value)
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			value = local
+		}
+		if len(value) >= 3 && strings.Contains(lower, value) {
+			return ErrPasswordPersonal
+		}
+	}
+
+	return nil
+}
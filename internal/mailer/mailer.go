@@ -6,6 +6,12 @@ const (
 	fromName               = "Social App"
 	maxRetries             = 3
 	UserInvitationTemplate = "user_invitation.tmpl"
+	PasswordResetTemplate  = "password_reset.tmpl"
+	AccountLockedTemplate  = "account_locked.tmpl"
+	MagicLinkTemplate      = "magic_link.tmpl"
+	NewDeviceLoginTemplate = "new_device_login.tmpl"
+	EmailChangeTemplate    = "email_change.tmpl"
+	EmailChangedTemplate   = "email_changed.tmpl"
 )
 
 //go:embed "templates"
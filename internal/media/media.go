@@ -0,0 +1,228 @@
+// Package media validates and processes uploaded images. Images are decoded
+// and re-encoded, which drops EXIF and other metadata such as GPS positions.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported media type, use JPEG, PNG or GIF")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+	ErrInvalidImage    = errors.New("invalid image")
+)
+
+const jpegQuality = 85
+
+// Variant is a fixed size thumbnail. The image is scaled down to fit in a
+// Size x Size box; smaller images are not enlarged.
+type Variant struct {
+	Name string
+	Size int
+}
+
+var Variants = []Variant{
+	{Name: "small", Size: 128},
+	{Name: "medium", Size: 512},
+	{Name: "large", Size: 1280},
+}
+
+// Image is a processed upload.
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+
+	img image.Image
+}
+
+// Sniff detects the content type from the leading bytes and reports whether
+// it is a supported image type. The client supplied type is never trusted.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return contentType, nil
+	default:
+		return "", ErrUnsupportedType
+	}
+}
+
+// Process sniffs, decodes and re-encodes data. JPEG orientation is applied
+// to the pixels so the image stays upright without its EXIF data.
+func Process(data []byte, maxPixels int) (*Image, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the dimensions before decoding to avoid decompression bombs.
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	var buf bytes.Buffer
+	var img image.Image
+
+	switch contentType {
+	case "image/gif":
+		// Keep animations, but rebuild the file from the decoded frames.
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		if len(anim.Image) == 0 {
+			return nil, fmt.Errorf("%w: no frames", ErrInvalidImage)
+		}
+		if err := gif.EncodeAll(&buf, &gif.GIF{
+			Image:     anim.Image,
+			Delay:     anim.Delay,
+			LoopCount: anim.LoopCount,
+			Disposal:  anim.Disposal,
+			Config:    anim.Config,
+		}); err != nil {
+			return nil, err
+		}
+		// Thumbnails use the first frame drawn on the full canvas.
+		canvas := image.NewRGBA(image.Rect(0, 0, anim.Config.Width, anim.Config.Height))
+		draw.Draw(canvas, anim.Image[0].Bounds(), anim.Image[0], anim.Image[0].Bounds().Min, draw.Over)
+		img = canvas
+	default:
+		img, _, err = image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		if contentType == "image/jpeg" {
+			img = applyOrientation(img, jpegOrientation(data))
+		}
+		if err := encode(&buf, img, contentType); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Image{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		img:         img,
+	}, nil
+}
+
+// Thumbnail renders the variant. JPEGs stay JPEGs, everything else becomes
+// a PNG to keep transparency.
+func (i *Image) Thumbnail(v Variant) (*Image, error) {
+	contentType := "image/png"
+	if i.ContentType == "image/jpeg" {
+		contentType = "image/jpeg"
+	}
+
+	img := resize(i.img, v.Size)
+
+	var buf bytes.Buffer
+	if err := encode(&buf, img, contentType); err != nil {
+		return nil, err
+	}
+
+	return &Image{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		img:         img,
+	}, nil
+}
+
+// Extension returns the file extension for a supported content type.
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ""
+	}
+}
+
+func encode(buf *bytes.Buffer, img image.Image, contentType string) error {
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.Encode(buf, img, &jpeg.Options{Quality: jpegQuality})
+	case "image/png":
+		return png.Encode(buf, img)
+	case "image/gif":
+		return gif.Encode(buf, img, nil)
+	default:
+		return ErrUnsupportedType
+	}
+}
+
+// resize scales img down to fit a size x size box using an area average,
+// which gives smooth results when shrinking.
+func resize(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	src := image.NewNRGBA64(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, max((x+1)*w/dw, x*w/dw+1)
+
+			// Weight colour by alpha so transparent pixels do not bleed.
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := src.NRGBA64At(sx, sy)
+					r += uint64(c.R) * uint64(c.A)
+					g += uint64(c.G) * uint64(c.A)
+					bl += uint64(c.B) * uint64(c.A)
+					a += uint64(c.A)
+					n++
+				}
+			}
+
+			if a == 0 {
+				dst.SetNRGBA(x, y, color.NRGBA{})
+				continue
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / a >> 8),
+				G: uint8(g / a >> 8),
+				B: uint8(bl / a >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,208 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeGIF(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withOrientation inserts an EXIF segment carrying the orientation right
+// after the JPEG start of image marker.
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	header := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(segment)+2))
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, header...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// halves returns a w x h image whose left half is red and right half blue.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func isRed(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > 0xC000 && b < 0x4000
+}
+
+func TestSniff(t *testing.T) {
+	img := halves(8, 8)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		err         error
+	}{
+		{name: "jpeg", data: encodeJPEG(t, img), contentType: "image/jpeg"},
+		{name: "png", data: encodePNG(t, img), contentType: "image/png"},
+		{name: "gif", data: encodeGIF(t, img), contentType: "image/gif"},
+		{name: "html", data: []byte("<html><body>hi</body></html>"), err: ErrUnsupportedType},
+		{name: "svg", data: []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`), err: ErrUnsupportedType},
+		{name: "empty", data: nil, err: ErrUnsupportedType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, err := Sniff(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if contentType != tt.contentType {
+				t.Errorf("expected %q, got %q", tt.contentType, contentType)
+			}
+		})
+	}
+}
+
+func TestProcessOrientation(t *testing.T) {
+	data := withOrientation(encodeJPEG(t, halves(32, 16)), 6)
+
+	if got := jpegOrientation(data); got != 6 {
+		t.Fatalf("expected orientation 6, got %d", got)
+	}
+
+	processed, err := Process(data, 1_000_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotating 90° clockwise turns the landscape image into a portrait one
+	// with the red left half on top.
+	if processed.Width != 16 || processed.Height != 32 {
+		t.Fatalf("expected 16x32, got %dx%d", processed.Width, processed.Height)
+	}
+	if !isRed(processed.img.At(8, 4)) || isRed(processed.img.At(8, 28)) {
+		t.Error("expected the red half on top")
+	}
+
+	if jpegOrientation(processed.Data) != 1 {
+		t.Error("expected the re-encoded image to carry no orientation")
+	}
+}
+
+func TestProcessRejectsLargeImages(t *testing.T) {
+	_, err := Process(encodePNG(t, halves(100, 100)), 9_999)
+	if !errors.Is(err, ErrTooManyPixels) {
+		t.Errorf("expected ErrTooManyPixels, got %v", err)
+	}
+}
+
+func TestThumbnailSizes(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		sizes         map[string][2]int
+		thumbnailType string
+	}{
+		{
+			name:          "landscape jpeg",
+			data:          encodeJPEG(t, halves(2000, 1000)),
+			sizes:         map[string][2]int{"small": {128, 64}, "medium": {512, 256}, "large": {1280, 640}},
+			thumbnailType: "image/jpeg",
+		},
+		{
+			name:          "portrait png",
+			data:          encodePNG(t, halves(600, 1200)),
+			sizes:         map[string][2]int{"small": {64, 128}, "medium": {256, 512}, "large": {600, 1200}},
+			thumbnailType: "image/png",
+		},
+		{
+			name:          "small gif",
+			data:          encodeGIF(t, halves(100, 50)),
+			sizes:         map[string][2]int{"small": {100, 50}, "medium": {100, 50}, "large": {100, 50}},
+			thumbnailType: "image/png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed, err := Process(tt.data, 10_000_000)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, v := range Variants {
+				thumbnail, err := processed.Thumbnail(v)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want := tt.sizes[v.Name]
+				if thumbnail.Width != want[0] || thumbnail.Height != want[1] {
+					t.Errorf("%s: expected %dx%d, got %dx%d", v.Name, want[0], want[1], thumbnail.Width, thumbnail.Height)
+				}
+				if thumbnail.ContentType != tt.thumbnailType {
+					t.Errorf("%s: expected %s, got %s", v.Name, tt.thumbnailType, thumbnail.ContentType)
+				}
+
+				config, _, err := image.DecodeConfig(bytes.NewReader(thumbnail.Data))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if config.Width != want[0] || config.Height != want[1] {
+					t.Errorf("%s: expected encoded %dx%d, got %dx%d", v.Name, want[0], want[1], config.Width, config.Height)
+				}
+			}
+		})
+	}
+}
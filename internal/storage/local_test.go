@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const localBaseURL = "http://localhost:8080/v1/media/files"
+
+func newTestLocalStorage(t *testing.T) *LocalStorage {
+	t.Helper()
+
+	s, err := NewLocalStorage(t.TempDir(), localBaseURL, "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Put(context.Background(), "media/1/original.png", strings.NewReader("image"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// serveSigned requests a signed URL from the storage's handler, the way the
+// application mounts it under the base URL.
+func serveSigned(t *testing.T, s *LocalStorage, signedURL string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, u.String(), nil)
+	req.URL.Path = strings.TrimPrefix(u.Path, "/v1/media/files")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestLocalStorageSignedURL(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should serve a valid signature", func(t *testing.T) {
+		s := newTestLocalStorage(t)
+
+		signedURL, err := s.SignedURL(ctx, "media/1/original.png", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(signedURL, localBaseURL+"/media/1/original.png?") {
+			t.Errorf("unexpected signed URL %q", signedURL)
+		}
+
+		rr := serveSigned(t, s, signedURL)
+		if rr.Code != http.StatusOK || rr.Body.String() != "image" {
+			t.Errorf("expected the object, got %d %q", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("should reject an expired signature", func(t *testing.T) {
+		s := newTestLocalStorage(t)
+
+		signedURL, err := s.SignedURL(ctx, "media/1/original.png", -time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if rr := serveSigned(t, s, signedURL); rr.Code != http.StatusForbidden {
+			t.Errorf("expected response code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+
+	t.Run("should reject a signature for another key", func(t *testing.T) {
+		s := newTestLocalStorage(t)
+
+		signedURL, err := s.SignedURL(ctx, "media/2/original.png", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signedURL = strings.Replace(signedURL, "media/2/", "media/1/", 1)
+
+		if rr := serveSigned(t, s, signedURL); rr.Code != http.StatusForbidden {
+			t.Errorf("expected response code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+
+	t.Run("should reject an extended expiry", func(t *testing.T) {
+		s := newTestLocalStorage(t)
+
+		signedURL, err := s.SignedURL(ctx, "media/1/original.png", -time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, _ := url.Parse(signedURL)
+		query := u.Query()
+		query.Set("expires", "9999999999")
+		u.RawQuery = query.Encode()
+
+		if rr := serveSigned(t, s, u.String()); rr.Code != http.StatusForbidden {
+			t.Errorf("expected response code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+
+	t.Run("should reject keys outside the storage root", func(t *testing.T) {
+		s := newTestLocalStorage(t)
+
+		if _, err := s.SignedURL(ctx, "../secret", time.Minute); err == nil {
+			t.Error("expected an error for a traversing key")
+		}
+		if err := s.Put(ctx, "media/../../secret", strings.NewReader("x"), "text/plain"); err == nil {
+			t.Error("expected an error for a traversing key")
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestS3Storage(t *testing.T) (*S3Storage, *MockS3Server) {
+	t.Helper()
+
+	server := NewMockS3Server()
+	t.Cleanup(server.Close)
+
+	s, err := NewS3Storage(server.Config(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, server
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+	const key = "media/1/original.png"
+
+	t.Run("should sign put, get and delete requests", func(t *testing.T) {
+		s, server := newTestS3Storage(t)
+
+		if err := s.Put(ctx, key, bytes.NewReader([]byte("image")), "image/png"); err != nil {
+			t.Fatal(err)
+		}
+		data, contentType, ok := server.Object(key)
+		if !ok || string(data) != "image" || contentType != "image/png" {
+			t.Fatalf("unexpected stored object %q %q %v", data, contentType, ok)
+		}
+
+		body, err := s.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(body)
+		body.Close()
+		if string(got) != "image" {
+			t.Errorf("expected %q, got %q", "image", got)
+		}
+
+		if err := s.Delete(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("should be rejected with the wrong secret", func(t *testing.T) {
+		_, server := newTestS3Storage(t)
+
+		config := server.Config()
+		config.SecretAccessKey = "wrong-secret"
+		wrong, err := NewS3Storage(config, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := wrong.Put(ctx, key, bytes.NewReader([]byte("image")), "image/png"); err == nil {
+			t.Error("expected the signature to be rejected")
+		}
+		if _, _, ok := server.Object(key); ok {
+			t.Error("expected nothing to be stored")
+		}
+	})
+
+	t.Run("should serve presigned URLs until they expire", func(t *testing.T) {
+		s, _ := newTestS3Storage(t)
+
+		if err := s.Put(ctx, key, bytes.NewReader([]byte("image")), "image/png"); err != nil {
+			t.Fatal(err)
+		}
+
+		signedURL, err := s.SignedURL(ctx, key, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code := getStatus(t, signedURL); code != http.StatusOK {
+			t.Errorf("expected response code %d, got %d", http.StatusOK, code)
+		}
+
+		tampered := strings.Replace(signedURL, "X-Amz-Expires=60", "X-Amz-Expires=600", 1)
+		if code := getStatus(t, tampered); code != http.StatusForbidden {
+			t.Errorf("expected response code %d for a tampered URL, got %d", http.StatusForbidden, code)
+		}
+
+		s.now = func() time.Time { return time.Now().Add(-time.Hour) }
+		expired, err := s.SignedURL(ctx, key, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code := getStatus(t, expired); code != http.StatusForbidden {
+			t.Errorf("expected response code %d for an expired URL, got %d", http.StatusForbidden, code)
+		}
+	})
+}
+
+func getStatus(t *testing.T, url string) int {
+	t.Helper()
+
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	return res.StatusCode
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage keeps blobs under slash separated keys such as
+// "media/<uuid>/original.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that allows anyone holding it to download the
+	// object until it expires.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// validateKey rejects keys that could escape the storage root.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package storage
+
+import "testing"
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{key: "media/123/original.jpg", valid: true},
+		{key: "original.jpg", valid: true},
+		{key: "", valid: false},
+		{key: "/etc/passwd", valid: false},
+		{key: "..", valid: false},
+		{key: "../secret", valid: false},
+		{key: "media/../../secret", valid: false},
+		{key: "media/./original.jpg", valid: false},
+		{key: "media//original.jpg", valid: false},
+		{key: "media/", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := validateKey(tt.key)
+			if tt.valid && err != nil {
+				t.Errorf("expected %q to be valid, got %v", tt.key, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected %q to be rejected", tt.key)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PurgePolicy decides what happens to a deactivated account once its
+// restore window has passed.
+type PurgePolicy string
+
+const (
+	// PurgeAnonymize keeps posts and comments under a "Deleted user"
+	// placeholder and erases everything that identifies the person.
+	PurgeAnonymize PurgePolicy = "anonymize"
+	// PurgeCascade deletes the account together with its content.
+	PurgeCascade PurgePolicy = "cascade"
+)
+
+// purgeBatchSize bounds the accounts handled per purge transaction.
+const purgeBatchSize = 100
+
+// personalDataTables hold data that only exists for the account's owner and
+// is removed when it is anonymized.
+var personalDataTables = []string{
+	"user_invitations",
+	"password_resets",
+	"refresh_tokens",
+	"revoked_tokens",
+	"sessions",
+	"user_totp",
+	"mfa_recovery_codes",
+	"personal_access_tokens",
+	"magic_links",
+	"user_identities",
+	"oidc_auth_requests",
+	"email_changes",
+	"bookmarks",
+	"bookmark_folders",
+	"reposts",
+}
+
+// Deactivate hides the account and schedules it for purging. It returns the
+// time it was deactivated.
+func (s *UserStore) Deactivate(ctx context.Context, id int64) (time.Time, error) {
+	query := `
+		UPDATE users SET deactivated_at = COALESCE(deactivated_at, now())
+		WHERE id = $1 AND anonymized_at IS NULL
+		RETURNING deactivated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var deactivatedAt time.Time
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&deactivatedAt)
+	return deactivatedAt, err
+}
+
+// Restore cancels a pending deletion.
+func (s *UserStore) Restore(ctx context.Context, id int64) error {
+	query := `
+		UPDATE users SET deactivated_at = NULL
+		WHERE id = $1 AND anonymized_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// PurgeDeactivated applies the policy to a batch of accounts deactivated
+// longer than restoreWindow ago. It returns the purged user IDs and the
+// storage keys of media blobs that are no longer referenced.
+func (s *UserStore) PurgeDeactivated(ctx context.Context, restoreWindow time.Duration, policy PurgePolicy) ([]int64, []string, error) {
+	if policy != PurgeAnonymize && policy != PurgeCascade {
+		return nil, nil, fmt.Errorf("unknown purge policy %q", policy)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var ids []int64
+	var keys []string
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT id FROM users
+			WHERE deactivated_at < now() - make_interval(secs => $1) AND anonymized_at IS NULL
+			ORDER BY deactivated_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		`
+
+		rows, err := tx.QueryContext(ctx, query, restoreWindow.Seconds(), purgeBatchSize)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// Unpublished posts were never shared, so they go with the account.
+		// Media attached to published posts stays with them.
+		if policy == PurgeAnonymize {
+			query = `
+				DELETE FROM posts WHERE user_id = ANY($1) AND status <> 'published'
+			`
+
+			if _, err := tx.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+				return err
+			}
+		}
+
+		query = `
+			DELETE FROM media m
+			WHERE m.user_id = ANY($1) AND ($2 OR NOT EXISTS (SELECT 1 FROM post_media pm WHERE pm.media_id = m.id))
+			RETURNING m.storage_key, m.variant_keys
+		`
+
+		mediaRows, err := tx.QueryContext(ctx, query, pq.Array(ids), policy == PurgeCascade)
+		if err != nil {
+			return err
+		}
+		defer mediaRows.Close()
+
+		for mediaRows.Next() {
+			var key string
+			var variants []string
+			if err := mediaRows.Scan(&key, pq.Array(&variants)); err != nil {
+				return err
+			}
+			keys = append(keys, key)
+			keys = append(keys, variants...)
+		}
+		if err := mediaRows.Err(); err != nil {
+			return err
+		}
+
+		if policy == PurgeCascade {
+			_, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ANY($1)`, pq.Array(ids))
+			return err
+		}
+
+		return anonymizeUsers(ctx, tx, ids)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ids, keys, nil
+}
+
+func anonymizeUsers(ctx context.Context, tx *sql.Tx, ids []int64) error {
+	for _, table := range personalDataTables {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE user_id = ANY($1)`, table)
+		if _, err := tx.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+			return err
+		}
+	}
+
+	queries := []string{
+		`DELETE FROM followers WHERE user_id = ANY($1) OR follower_id = ANY($1)`,
+		`DELETE FROM follow_requests WHERE user_id = ANY($1) OR requester_id = ANY($1)`,
+		`DELETE FROM user_blocks WHERE blocker_id = ANY($1) OR blocked_id = ANY($1)`,
+		`DELETE FROM user_mutes WHERE muter_id = ANY($1) OR muted_id = ANY($1)`,
+		`DELETE FROM login_failures lf USING users u
+			WHERE u.id = ANY($1) AND lf.scope = 'account' AND lf.subject = lower(u.email)`,
+		`UPDATE users SET
+			username = 'deleted-user-' || id,
+			email = 'deleted-user-' || id || '@deleted.invalid',
+			password = '',
+			display_name = 'Deleted user',
+			bio = '',
+			avatar_url = '',
+			avatar_media_id = NULL,
+			links = '{}',
+			location = '',
+			is_private = false,
+			token_generation = token_generation + 1,
+			anonymized_at = now(),
+			updated_at = now()
+		WHERE id = ANY($1)`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
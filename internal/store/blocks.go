@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/utils"
+	"github.com/lib/pq"
+)
+
+type BlockStore struct {
+	db *sql.DB
+}
+
+func NewBlockStore(db *sql.DB) *BlockStore {
+	return &BlockStore{db: db}
+}
+
+// RelatedUser is an entry of a block or mute list.
+type RelatedUser struct {
+	PublicUser
+	Since time.Time `json:"since" example:"2021-01-01T00:00:00Z"`
+}
+
+// Block makes blockerID block blockedID and removes follows and follow
+// requests in both directions. It returns ErrSelfBlock, ErrAlreadyBlocked,
+// or ErrNotFound when the user does not exist.
+func (s *BlockStore) Block(ctx context.Context, blockerID, blockedID int64) error {
+	if blockerID == blockedID {
+		return ErrSelfBlock
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO user_blocks (blocker_id, blocked_id)
+			VALUES ($1, $2)
+		`
+
+		_, err := tx.ExecContext(ctx, query, blockerID, blockedID)
+		switch pgErrorCode(err) {
+		case pgUniqueViolation:
+			return ErrAlreadyBlocked
+		case pgForeignKeyViolation:
+			return ErrNotFound
+		case pgCheckViolation:
+			return ErrSelfBlock
+		}
+		if err != nil {
+			return err
+		}
+
+		query = `
+			DELETE FROM followers
+			WHERE (user_id = $1 AND follower_id = $2) OR (user_id = $2 AND follower_id = $1)
+		`
+
+		if _, err := tx.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+			return err
+		}
+
+		query = `
+			DELETE FROM follow_requests
+			WHERE (user_id = $1 AND requester_id = $2) OR (user_id = $2 AND requester_id = $1)
+		`
+
+		_, err = tx.ExecContext(ctx, query, blockerID, blockedID)
+		return err
+	})
+}
+
+func (s *BlockStore) Unblock(ctx context.Context, blockerID, blockedID int64) error {
+	query := `
+		DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2
+	`
+
+	return s.deleteRelation(ctx, query, blockerID, blockedID, ErrNotBlocked)
+}
+
+// IsBlocked reports whether either user blocked the other.
+func (s *BlockStore) IsBlocked(ctx context.Context, userID, otherID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var blocked bool
+	err := s.db.QueryRowContext(ctx, query, userID, otherID).Scan(&blocked)
+	return blocked, err
+}
+
+// ReadBlocked lists the users blockerID blocked, most recent first.
+func (s *BlockStore) ReadBlocked(ctx context.Context, blockerID int64, page utils.PaginationQuery) ([]RelatedUser, error) {
+	query := `
+		SELECT u.id, u.username, u.created_at, u.display_name, u.bio, u.avatar_url, u.links, u.location, u.is_private, b.created_at
+		FROM user_blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = $1
+		ORDER BY b.created_at DESC, u.id DESC
+		OFFSET $2 LIMIT $3
+	`
+
+	return s.readRelated(ctx, query, blockerID, page)
+}
+
+// Mute hides mutedID's posts from muterID's feed. The muted user is not
+// told.
+func (s *BlockStore) Mute(ctx context.Context, muterID, mutedID int64) error {
+	if muterID == mutedID {
+		return ErrSelfMute
+	}
+
+	query := `
+		INSERT INTO user_mutes (muter_id, muted_id)
+		VALUES ($1, $2)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, muterID, mutedID)
+	switch pgErrorCode(err) {
+	case pgUniqueViolation:
+		return ErrAlreadyMuted
+	case pgForeignKeyViolation:
+		return ErrNotFound
+	case pgCheckViolation:
+		return ErrSelfMute
+	}
+
+	return err
+}
+
+func (s *BlockStore) Unmute(ctx context.Context, muterID, mutedID int64) error {
+	query := `
+		DELETE FROM user_mutes WHERE muter_id = $1 AND muted_id = $2
+	`
+
+	return s.deleteRelation(ctx, query, muterID, mutedID, ErrNotMuted)
+}
+
+// ReadMuted lists the users muterID muted, most recent first.
+func (s *BlockStore) ReadMuted(ctx context.Context, muterID int64, page utils.PaginationQuery) ([]RelatedUser, error) {
+	query := `
+		SELECT u.id, u.username, u.created_at, u.display_name, u.bio, u.avatar_url, u.links, u.location, u.is_private, m.created_at
+		FROM user_mutes m
+		JOIN users u ON u.id = m.muted_id
+		WHERE m.muter_id = $1
+		ORDER BY m.created_at DESC, u.id DESC
+		OFFSET $2 LIMIT $3
+	`
+
+	return s.readRelated(ctx, query, muterID, page)
+}
+
+func (s *BlockStore) deleteRelation(ctx context.Context, query string, userID, otherID int64, notFound error) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, userID, otherID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+func (s *BlockStore) readRelated(ctx context.Context, query string, userID int64, page utils.PaginationQuery) ([]RelatedUser, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID, page.Offset, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []RelatedUser{}
+	for rows.Next() {
+		var u RelatedUser
+		err := rows.Scan(&u.ID, &u.Username, &u.CreatedAt, &u.DisplayName, &u.Bio, &u.AvatarURL, pq.Array(&u.Links), &u.Location, &u.IsPrivate, &u.Since)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
@@ -0,0 +1,280 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/utils"
+	"github.com/lib/pq"
+)
+
+type BookmarkStore struct {
+	db *sql.DB
+}
+
+func NewBookmarkStore(db *sql.DB) *BookmarkStore {
+	return &BookmarkStore{db: db}
+}
+
+// Bookmark is a post a user saved, optionally sorted into one of their
+// folders.
+type Bookmark struct {
+	Post         Post      `json:"post"`
+	FolderID     *int64    `json:"folder_id" example:"1"`
+	BookmarkedAt time.Time `json:"bookmarked_at" example:"2021-01-01T00:00:00Z"`
+}
+
+type BookmarkFolder struct {
+	ID        int64     `json:"id" example:"1"`
+	Name      string    `json:"name" example:"Read later"`
+	Count     int64     `json:"count" example:"12"`
+	CreatedAt time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// BookmarkFilter narrows a bookmark listing to a folder. A nil FolderID
+// lists every bookmark, Unfiled only those outside any folder.
+type BookmarkFilter struct {
+	FolderID *int64
+	Unfiled  bool
+}
+
+// Add saves the post for userID. It returns ErrAlreadyBookmarked, or
+// ErrNotFound when the post or folder does not exist.
+func (s *BookmarkStore) Add(ctx context.Context, userID, postID int64, folderID *int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := checkBookmarkFolder(ctx, tx, userID, folderID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO bookmarks (user_id, post_id, folder_id)
+			VALUES ($1, $2, $3)
+		`
+
+		_, err := tx.ExecContext(ctx, query, userID, postID, folderID)
+		switch pgErrorCode(err) {
+		case pgUniqueViolation:
+			return ErrAlreadyBookmarked
+		case pgForeignKeyViolation:
+			return ErrNotFound
+		}
+
+		return err
+	})
+}
+
+// Move puts the bookmark into another folder, or out of any folder when
+// folderID is nil. It returns ErrNotBookmarked, or ErrNotFound when the
+// folder does not exist.
+func (s *BookmarkStore) Move(ctx context.Context, userID, postID int64, folderID *int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := checkBookmarkFolder(ctx, tx, userID, folderID); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE bookmarks SET folder_id = $3
+			WHERE user_id = $1 AND post_id = $2
+		`
+
+		result, err := tx.ExecContext(ctx, query, userID, postID, folderID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return ErrNotBookmarked
+		}
+
+		return nil
+	})
+}
+
+func (s *BookmarkStore) Remove(ctx context.Context, userID, postID int64) error {
+	query := `
+		DELETE FROM bookmarks WHERE user_id = $1 AND post_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, userID, postID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotBookmarked
+	}
+
+	return nil
+}
+
+// ReadByUserID lists userID's bookmarks, most recently saved first. Posts
+// the user can no longer see are left out.
+func (s *BookmarkStore) ReadByUserID(ctx context.Context, userID int64, filter BookmarkFilter, page utils.PaginationQuery) ([]Bookmark, error) {
+	query := `
+		SELECT p.id, p.title, p.content, p.user_id, p.tags, p.created_at, p.updated_at, b.folder_id, b.created_at
+		FROM bookmarks b
+		JOIN posts p ON p.id = b.post_id
+		JOIN users u ON u.id = p.user_id
+		WHERE b.user_id = $1 AND
+			($2::bigint IS NULL OR b.folder_id = $2) AND
+			(NOT $3 OR b.folder_id IS NULL) AND
+			(p.status = 'published' OR p.user_id = $1) AND
+			` + visibleToClause("u", "$1") + `
+		ORDER BY b.created_at DESC, p.id DESC
+		OFFSET $4 LIMIT $5
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID, filter.FolderID, filter.Unfiled, page.Offset, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookmarks := []Bookmark{}
+	for rows.Next() {
+		var b Bookmark
+		err := rows.Scan(
+			&b.Post.ID, &b.Post.Title, &b.Post.Content, &b.Post.UserID, pq.Array(&b.Post.Tags), &b.Post.CreatedAt, &b.Post.UpdatedAt,
+			&b.FolderID, &b.BookmarkedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bookmarks, nil
+}
+
+// CreateFolder returns ErrBookmarkFolderExists when the user already has a
+// folder with that name.
+func (s *BookmarkStore) CreateFolder(ctx context.Context, userID int64, name string) (*BookmarkFolder, error) {
+	query := `
+		INSERT INTO bookmark_folders (user_id, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	folder := BookmarkFolder{Name: name}
+	err := s.db.QueryRowContext(ctx, query, userID, name).Scan(&folder.ID, &folder.CreatedAt)
+	if pgErrorCode(err) == pgUniqueViolation {
+		return nil, ErrBookmarkFolderExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &folder, nil
+}
+
+// ReadFolders lists userID's folders by name with how many bookmarks each
+// holds.
+func (s *BookmarkStore) ReadFolders(ctx context.Context, userID int64) ([]BookmarkFolder, error) {
+	query := `
+		SELECT bf.id, bf.name, bf.created_at, COUNT(b.post_id)
+		FROM bookmark_folders bf
+		LEFT JOIN bookmarks b ON b.folder_id = bf.id
+		WHERE bf.user_id = $1
+		GROUP BY bf.id
+		ORDER BY bf.name
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folders := []BookmarkFolder{}
+	for rows.Next() {
+		var f BookmarkFolder
+		if err := rows.Scan(&f.ID, &f.Name, &f.CreatedAt, &f.Count); err != nil {
+			return nil, err
+		}
+		folders = append(folders, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
+// DeleteFolder removes the folder. Its bookmarks are kept outside any
+// folder.
+func (s *BookmarkStore) DeleteFolder(ctx context.Context, userID, folderID int64) error {
+	query := `
+		DELETE FROM bookmark_folders WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, folderID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// checkBookmarkFolder returns ErrNotFound unless folderID is nil or one of
+// userID's folders. The folder is locked until the transaction ends, so it
+// cannot be deleted in between.
+func checkBookmarkFolder(ctx context.Context, tx *sql.Tx, userID int64, folderID *int64) error {
+	if folderID == nil {
+		return nil
+	}
+
+	query := `
+		SELECT id FROM bookmark_folders WHERE id = $1 AND user_id = $2 FOR SHARE
+	`
+
+	var id int64
+	err := tx.QueryRowContext(ctx, query, *folderID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+
+	return err
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"time"
 
 	"github.com/andras-szesztai/social/internal/store"
 	"github.com/stretchr/testify/mock"
@@ -9,7 +10,8 @@ import (
 
 func NewMockCache() *Storage {
 	return &Storage{
-		Users: &MockUserCache{},
+		Users:  &MockUserCache{},
+		Tokens: &MockTokenCache{},
 	}
 }
 
@@ -31,3 +33,37 @@ func (m *MockUserCache) Delete(ctx context.Context, id int64) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+
+type MockTokenCache struct {
+	mock.Mock
+}
+
+func (m *MockTokenCache) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	args := m.Called(jti, ttl)
+	return args.Error(0)
+}
+
+func (m *MockTokenCache) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenCache) GetGeneration(ctx context.Context, userID int64) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenCache) SetGeneration(ctx context.Context, userID, generation int64) error {
+	args := m.Called(userID, generation)
+	return args.Error(0)
+}
+
+func (m *MockTokenCache) RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	args := m.Called(sessionID, ttl)
+	return args.Error(0)
+}
+
+func (m *MockTokenCache) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	args := m.Called(sessionID)
+	return args.Bool(0), args.Error(1)
+}
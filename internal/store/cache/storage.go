@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"time"
 
 	"github.com/andras-szesztai/social/internal/store"
 )
@@ -12,10 +13,19 @@ type Storage struct {
 		Set(ctx context.Context, user *store.User) error
 		Delete(ctx context.Context, id int64) error
 	}
+	Tokens interface {
+		Revoke(ctx context.Context, jti string, ttl time.Duration) error
+		IsRevoked(ctx context.Context, jti string) (bool, error)
+		GetGeneration(ctx context.Context, userID int64) (int64, error)
+		SetGeneration(ctx context.Context, userID, generation int64) error
+		RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error
+		IsSessionRevoked(ctx context.Context, sessionID string) (bool, error)
+	}
 }
 
 func NewRedisStorage(redis *RedisCache) *Storage {
 	return &Storage{
-		Users: NewUserStorage(redis),
+		Users:  NewUserStorage(redis),
+		Tokens: NewTokenStorage(redis),
 	}
 }
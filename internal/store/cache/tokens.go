@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type TokenStorage struct {
+	redis *RedisCache
+}
+
+func NewTokenStorage(redis *RedisCache) *TokenStorage {
+	return &TokenStorage{redis: redis}
+}
+
+func (s *TokenStorage) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	cacheKey := fmt.Sprintf("revoked_token:%s", jti)
+	return s.redis.Client.SetEx(ctx, cacheKey, 1, ttl).Err()
+}
+
+func (s *TokenStorage) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	cacheKey := fmt.Sprintf("revoked_token:%s", jti)
+	count, err := s.redis.Client.Exists(ctx, cacheKey).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (s *TokenStorage) GetGeneration(ctx context.Context, userID int64) (int64, error) {
+	cacheKey := fmt.Sprintf("token_generation:%d", userID)
+	generation, err := s.redis.Client.Get(ctx, cacheKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(generation, 10, 64)
+}
+
+func (s *TokenStorage) SetGeneration(ctx context.Context, userID, generation int64) error {
+	cacheKey := fmt.Sprintf("token_generation:%d", userID)
+	return s.redis.Client.SetEx(ctx, cacheKey, generation, 1*time.Hour).Err()
+}
+
+func (s *TokenStorage) RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	cacheKey := fmt.Sprintf("revoked_session:%s", sessionID)
+	return s.redis.Client.SetEx(ctx, cacheKey, 1, ttl).Err()
+}
+
+func (s *TokenStorage) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	cacheKey := fmt.Sprintf("revoked_session:%s", sessionID)
+	count, err := s.redis.Client.Exists(ctx, cacheKey).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type EmailChangeStore struct {
+	db *sql.DB
+}
+
+func NewEmailChangeStore(db *sql.DB) *EmailChangeStore {
+	return &EmailChangeStore{db: db}
+}
+
+type EmailChange struct {
+	ID       int64
+	UserID   int64
+	OldEmail string
+	NewEmail string
+}
+
+// Create starts changing the user's email to newEmail, replacing any change
+// that is still waiting for confirmation. It fails early with
+// ErrEmailAlreadyExists when the address is taken; uniqueness is enforced
+// again on confirmation.
+func (s *EmailChangeStore) Create(ctx context.Context, change *EmailChange, token string, expiry time.Duration) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT EXISTS (SELECT 1 FROM users WHERE email = $1)
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		var taken bool
+		if err := tx.QueryRowContext(ctx, query, change.NewEmail).Scan(&taken); err != nil {
+			return err
+		}
+		if taken {
+			return ErrEmailAlreadyExists
+		}
+
+		query = `
+			DELETE FROM email_changes WHERE user_id = $1 AND confirmed_at IS NULL
+		`
+
+		if _, err := tx.ExecContext(ctx, query, change.UserID); err != nil {
+			return err
+		}
+
+		query = `
+			INSERT INTO email_changes (user_id, old_email, new_email, token, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`
+
+		return tx.QueryRowContext(ctx, query, change.UserID, change.OldEmail, change.NewEmail, token, time.Now().Add(expiry)).Scan(&change.ID)
+	})
+}
+
+// Confirm swaps the user's email to the new address and arms the revert
+// token, which the previous address can use within revertWindow.
+func (s *EmailChangeStore) Confirm(ctx context.Context, token, revertToken string, revertWindow time.Duration) (*EmailChange, error) {
+	var change EmailChange
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT id, user_id, old_email, new_email, expires_at
+			FROM email_changes
+			WHERE token = $1 AND confirmed_at IS NULL
+			FOR UPDATE
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		var expiresAt time.Time
+		err := tx.QueryRowContext(ctx, query, token).Scan(&change.ID, &change.UserID, &change.OldEmail, &change.NewEmail, &expiresAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		if time.Now().After(expiresAt) {
+			return ErrEmailChangeExpired
+		}
+
+		if err := setUserEmail(ctx, tx, change.UserID, change.OldEmail, change.NewEmail); err != nil {
+			return err
+		}
+
+		query = `
+			UPDATE email_changes
+			SET confirmed_at = now(), revert_token = $2, revert_expires_at = $3
+			WHERE id = $1
+		`
+
+		_, err = tx.ExecContext(ctx, query, change.ID, revertToken, time.Now().Add(revertWindow))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// Revert restores the address the email was changed from.
+func (s *EmailChangeStore) Revert(ctx context.Context, revertToken string) (*EmailChange, error) {
+	var change EmailChange
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT id, user_id, old_email, new_email, revert_expires_at
+			FROM email_changes
+			WHERE revert_token = $1 AND reverted_at IS NULL
+			FOR UPDATE
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		var expiresAt time.Time
+		err := tx.QueryRowContext(ctx, query, revertToken).Scan(&change.ID, &change.UserID, &change.OldEmail, &change.NewEmail, &expiresAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		if time.Now().After(expiresAt) {
+			return ErrEmailChangeExpired
+		}
+
+		if err := setUserEmail(ctx, tx, change.UserID, change.NewEmail, change.OldEmail); err != nil {
+			return err
+		}
+
+		query = `
+			UPDATE email_changes SET reverted_at = now() WHERE id = $1
+		`
+
+		_, err = tx.ExecContext(ctx, query, change.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// setUserEmail changes the email only while it still is from, so a stale
+// change cannot overwrite a newer one.
+func setUserEmail(ctx context.Context, tx *sql.Tx, userID int64, from, to string) error {
+	query := `
+		UPDATE users SET email = $3, updated_at = now()
+		WHERE id = $1 AND email = $2
+	`
+
+	result, err := tx.ExecContext(ctx, query, userID, from, to)
+	if err != nil {
+		switch err.Error() {
+		case "pq: duplicate key value violates unique constraint \"users_email_key\"":
+			return ErrEmailAlreadyExists
+		default:
+			return err
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
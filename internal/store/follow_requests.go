@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/utils"
+	"github.com/lib/pq"
+)
+
+type FollowRequestStore struct {
+	db *sql.DB
+}
+
+func NewFollowRequestStore(db *sql.DB) *FollowRequestStore {
+	return &FollowRequestStore{db: db}
+}
+
+// ReadByUserID lists the pending requests to follow userID, oldest first.
+func (s *FollowRequestStore) ReadByUserID(ctx context.Context, userID int64, page utils.PaginationQuery) ([]RelatedUser, error) {
+	query := `
+		SELECT u.id, u.username, u.created_at, u.display_name, u.bio, u.avatar_url, u.links, u.location, u.is_private, fr.created_at
+		FROM follow_requests fr
+		JOIN users u ON u.id = fr.requester_id
+		WHERE fr.user_id = $1
+		ORDER BY fr.created_at, u.id
+		OFFSET $2 LIMIT $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID, page.Offset, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requesters := []RelatedUser{}
+	for rows.Next() {
+		var u RelatedUser
+		err := rows.Scan(&u.ID, &u.Username, &u.CreatedAt, &u.DisplayName, &u.Bio, &u.AvatarURL, pq.Array(&u.Links), &u.Location, &u.IsPrivate, &u.Since)
+		if err != nil {
+			return nil, err
+		}
+		requesters = append(requesters, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requesters, nil
+}
+
+// Approve turns the request into a follow. It returns ErrNotFound when
+// there is no such request.
+func (s *FollowRequestStore) Approve(ctx context.Context, userID, requesterID int64) error {
+	query := `
+		WITH approved AS (
+			DELETE FROM follow_requests WHERE user_id = $1 AND requester_id = $2
+			RETURNING user_id, requester_id
+		)
+		INSERT INTO followers (user_id, follower_id)
+		SELECT user_id, requester_id FROM approved
+		ON CONFLICT DO NOTHING
+		RETURNING 1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var approved int
+	err := s.db.QueryRowContext(ctx, query, userID, requesterID).Scan(&approved)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+// Reject deletes the request. It returns ErrNotFound when there is no such
+// request.
+func (s *FollowRequestStore) Reject(ctx context.Context, userID, requesterID int64) error {
+	query := `
+		DELETE FROM follow_requests WHERE user_id = $1 AND requester_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, userID, requesterID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
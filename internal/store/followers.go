@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/utils"
+	"github.com/lib/pq"
+)
+
+type UserStats struct {
+	FollowerCount  int64 `json:"follower_count" example:"42"`
+	FollowingCount int64 `json:"following_count" example:"7"`
+	PostCount      int64 `json:"post_count" example:"12"`
+}
+
+// Relationship describes a user as seen by the authenticated caller.
+type Relationship struct {
+	IsFollowedByMe bool `json:"is_followed_by_me" example:"true"`
+	FollowsMe      bool `json:"follows_me" example:"false"`
+	IsBlockedByMe  bool `json:"is_blocked_by_me" example:"false"`
+	IsMutedByMe    bool `json:"is_muted_by_me" example:"false"`
+}
+
+// Connection is an entry of a followers or following list.
+type Connection struct {
+	PublicUser
+	FollowedAt time.Time `json:"followed_at" example:"2021-01-01T00:00:00Z"`
+}
+
+func (s *UserStore) ReadStats(ctx context.Context, userID int64) (*UserStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM followers f JOIN users u ON u.id = f.follower_id WHERE f.user_id = $1 AND u.deactivated_at IS NULL),
+			(SELECT COUNT(*) FROM followers f JOIN users u ON u.id = f.user_id WHERE f.follower_id = $1 AND u.deactivated_at IS NULL),
+			(SELECT COUNT(*) FROM posts WHERE user_id = $1 AND status = 'published')
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var stats UserStats
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&stats.FollowerCount, &stats.FollowingCount, &stats.PostCount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// ReadRelationship returns how userID relates to viewerID.
+func (s *UserStore) ReadRelationship(ctx context.Context, userID, viewerID int64) (*Relationship, error) {
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM followers WHERE user_id = $1 AND follower_id = $2),
+			EXISTS (SELECT 1 FROM followers WHERE user_id = $2 AND follower_id = $1),
+			EXISTS (SELECT 1 FROM user_blocks WHERE blocker_id = $2 AND blocked_id = $1),
+			EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = $2 AND muted_id = $1)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var rel Relationship
+	err := s.db.QueryRowContext(ctx, query, userID, viewerID).Scan(&rel.IsFollowedByMe, &rel.FollowsMe, &rel.IsBlockedByMe, &rel.IsMutedByMe)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rel, nil
+}
+
+// ReadFollowers lists the users following userID, most recent first, with
+// their relationship to viewerID. Deactivated accounts are left out, as they
+// are from the counts in ReadStats.
+func (s *UserStore) ReadFollowers(ctx context.Context, userID, viewerID int64, page utils.PaginationQuery) ([]Connection, error) {
+	return s.readConnections(ctx, `
+		SELECT u.id, u.username, u.created_at, u.display_name, u.bio, u.avatar_url, u.links, u.location, u.is_private, f.created_at,
+			EXISTS (SELECT 1 FROM followers x WHERE x.user_id = u.id AND x.follower_id = $2),
+			EXISTS (SELECT 1 FROM followers x WHERE x.user_id = $2 AND x.follower_id = u.id),
+			EXISTS (SELECT 1 FROM user_blocks x WHERE x.blocker_id = $2 AND x.blocked_id = u.id),
+			EXISTS (SELECT 1 FROM user_mutes x WHERE x.muter_id = $2 AND x.muted_id = u.id)
+		FROM followers f
+		JOIN users u ON u.id = f.follower_id
+		WHERE f.user_id = $1 AND u.deactivated_at IS NULL
+		ORDER BY f.created_at DESC, u.id DESC
+		OFFSET $3 LIMIT $4
+	`, userID, viewerID, page)
+}
+
+// ReadFollowing lists the users userID follows, most recent first, with
+// their relationship to viewerID.
+func (s *UserStore) ReadFollowing(ctx context.Context, userID, viewerID int64, page utils.PaginationQuery) ([]Connection, error) {
+	return s.readConnections(ctx, `
+		SELECT u.id, u.username, u.created_at, u.display_name, u.bio, u.avatar_url, u.links, u.location, u.is_private, f.created_at,
+			EXISTS (SELECT 1 FROM followers x WHERE x.user_id = u.id AND x.follower_id = $2),
+			EXISTS (SELECT 1 FROM followers x WHERE x.user_id = $2 AND x.follower_id = u.id),
+			EXISTS (SELECT 1 FROM user_blocks x WHERE x.blocker_id = $2 AND x.blocked_id = u.id),
+			EXISTS (SELECT 1 FROM user_mutes x WHERE x.muter_id = $2 AND x.muted_id = u.id)
+		FROM followers f
+		JOIN users u ON u.id = f.user_id
+		WHERE f.follower_id = $1 AND u.deactivated_at IS NULL
+		ORDER BY f.created_at DESC, u.id DESC
+		OFFSET $3 LIMIT $4
+	`, userID, viewerID, page)
+}
+
+func (s *UserStore) readConnections(ctx context.Context, query string, userID, viewerID int64, page utils.PaginationQuery) ([]Connection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID, viewerID, page.Offset, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	connections := []Connection{}
+	for rows.Next() {
+		c := Connection{PublicUser: PublicUser{Relationship: &Relationship{}}}
+		err := rows.Scan(
+			&c.ID, &c.Username, &c.CreatedAt, &c.DisplayName, &c.Bio, &c.AvatarURL, pq.Array(&c.Links), &c.Location, &c.IsPrivate, &c.FollowedAt,
+			&c.Relationship.IsFollowedByMe, &c.Relationship.FollowsMe, &c.Relationship.IsBlockedByMe, &c.Relationship.IsMutedByMe,
+		)
+		if err != nil {
+			return nil, err
+		}
+		connections = append(connections, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return connections, nil
+}
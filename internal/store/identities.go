@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type IdentityStore struct {
+	db *sql.DB
+}
+
+func NewIdentityStore(db *sql.DB) *IdentityStore {
+	return &IdentityStore{db: db}
+}
+
+// Identity links a user to an account at an external identity provider.
+type Identity struct {
+	ID        int64     `json:"id" example:"1"`
+	UserID    int64     `json:"user_id" example:"1"`
+	Provider  string    `json:"provider" example:"google"`
+	Subject   string    `json:"-"`
+	Email     string    `json:"email" example:"john.doe@example.com"`
+	CreatedAt time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// OIDCAuthRequest is a pending sign in at an identity provider. UserID is
+// set when an already authenticated user is linking a new identity.
+type OIDCAuthRequest struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	UserID       *int64
+	ExpiresAt    time.Time
+}
+
+func (s *IdentityStore) Create(ctx context.Context, identity *Identity) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return createIdentity(ctx, s.db, identity)
+}
+
+func (s *IdentityStore) ReadByProviderSubject(ctx context.Context, provider, subject string) (*Identity, error) {
+	query := `
+		SELECT id, user_id, provider, subject, COALESCE(email, ''), created_at
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var identity Identity
+	err := s.db.QueryRowContext(ctx, query, provider, subject).Scan(
+		&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (s *IdentityStore) ReadByUserID(ctx context.Context, userID int64) ([]Identity, error) {
+	query := `
+		SELECT id, user_id, provider, subject, COALESCE(email, ''), created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []Identity{}
+	for rows.Next() {
+		var identity Identity
+		err := rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (s *IdentityStore) Delete(ctx context.Context, id, userID int64) error {
+	query := `
+		DELETE FROM user_identities WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *IdentityStore) CreateAuthRequest(ctx context.Context, req *OIDCAuthRequest) error {
+	query := `
+		INSERT INTO oidc_auth_requests (state, provider, nonce, code_verifier, user_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, req.State, req.Provider, req.Nonce, req.CodeVerifier, req.UserID, req.ExpiresAt)
+	return err
+}
+
+// ConsumeAuthRequest deletes and returns the pending sign in for the state,
+// so every state can only complete one callback.
+func (s *IdentityStore) ConsumeAuthRequest(ctx context.Context, state string) (*OIDCAuthRequest, error) {
+	query := `
+		DELETE FROM oidc_auth_requests WHERE state = $1
+		RETURNING state, provider, nonce, code_verifier, user_id, expires_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var req OIDCAuthRequest
+	var userID sql.NullInt64
+	err := s.db.QueryRowContext(ctx, query, state).Scan(&req.State, &req.Provider, &req.Nonce, &req.CodeVerifier, &userID, &req.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if userID.Valid {
+		req.UserID = &userID.Int64
+	}
+	if time.Now().After(req.ExpiresAt) {
+		return nil, ErrOIDCAuthRequestExpired
+	}
+
+	return &req, nil
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func createIdentity(ctx context.Context, db rowQuerier, identity *Identity) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING id, created_at
+	`
+
+	err := db.QueryRowContext(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email).Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		switch err.Error() {
+		case "pq: duplicate key value violates unique constraint \"unique_user_identity\"":
+			return ErrIdentityAlreadyLinked
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
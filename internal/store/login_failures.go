@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	LoginFailureScopeAccount = "account"
+	LoginFailureScopeIP      = "ip"
+	// LoginFailureScopeMFA counts wrong codes presented with one MFA
+	// challenge token, keyed by its jti.
+	LoginFailureScopeMFA = "mfa"
+)
+
+type LoginFailureStore struct {
+	db *sql.DB
+}
+
+func NewLoginFailureStore(db *sql.DB) *LoginFailureStore {
+	return &LoginFailureStore{db: db}
+}
+
+type LoginFailure struct {
+	ID            int64      `json:"id" example:"1"`
+	Scope         string     `json:"scope" example:"account"`
+	Subject       string     `json:"subject" example:"john.doe@example.com"`
+	Failures      int        `json:"failures" example:"5"`
+	LockedUntil   *time.Time `json:"locked_until" example:"2021-01-01T00:00:00Z"`
+	LastFailureAt time.Time  `json:"last_failure_at" example:"2021-01-01T00:00:00Z"`
+}
+
+func (s *LoginFailureStore) Read(ctx context.Context, scope, subject string) (*LoginFailure, error) {
+	query := `
+		SELECT id, scope, subject, failures, locked_until, last_failure_at
+		FROM login_failures
+		WHERE scope = $1 AND subject = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return scanLoginFailure(s.db.QueryRowContext(ctx, query, scope, subject))
+}
+
+// RecordFailure counts a failed login for the subject. The count starts over
+// when the previous failure is older than resetAfter.
+func (s *LoginFailureStore) RecordFailure(ctx context.Context, scope, subject string, resetAfter time.Duration) (*LoginFailure, error) {
+	query := `
+		INSERT INTO login_failures (scope, subject, failures)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (scope, subject) DO UPDATE
+			SET failures = CASE
+					WHEN login_failures.last_failure_at < now() - make_interval(secs => $3) THEN 1
+					ELSE login_failures.failures + 1
+				END,
+				last_failure_at = now()
+		RETURNING id, scope, subject, failures, locked_until, last_failure_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return scanLoginFailure(s.db.QueryRowContext(ctx, query, scope, subject, resetAfter.Seconds()))
+}
+
+func (s *LoginFailureStore) Lock(ctx context.Context, id int64, until time.Time) error {
+	query := `
+		UPDATE login_failures SET locked_until = $2 WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, id, until)
+	return err
+}
+
+func (s *LoginFailureStore) Reset(ctx context.Context, scope, subject string) error {
+	query := `
+		DELETE FROM login_failures WHERE scope = $1 AND subject = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, scope, subject)
+	return err
+}
+
+// ReadLocked lists every subject that is currently locked out.
+func (s *LoginFailureStore) ReadLocked(ctx context.Context) ([]LoginFailure, error) {
+	query := `
+		SELECT id, scope, subject, failures, locked_until, last_failure_at
+		FROM login_failures
+		WHERE locked_until > now()
+		ORDER BY locked_until DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	failures := []LoginFailure{}
+	for rows.Next() {
+		failure, err := scanLoginFailure(rows)
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, *failure)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return failures, nil
+}
+
+func (s *LoginFailureStore) Delete(ctx context.Context, id int64) error {
+	query := `
+		DELETE FROM login_failures WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func scanLoginFailure(row rowScanner) (*LoginFailure, error) {
+	var failure LoginFailure
+	var lockedUntil sql.NullTime
+	err := row.Scan(&failure.ID, &failure.Scope, &failure.Subject, &failure.Failures, &lockedUntil, &failure.LastFailureAt)
+	if err != nil {
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		failure.LockedUntil = &lockedUntil.Time
+	}
+
+	return &failure, nil
+}
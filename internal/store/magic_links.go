@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type MagicLinkStore struct {
+	db *sql.DB
+}
+
+func NewMagicLinkStore(db *sql.DB) *MagicLinkStore {
+	return &MagicLinkStore{db: db}
+}
+
+// Create stores a new login link for the user, invalidating any link that was
+// sent before.
+func (s *MagicLinkStore) Create(ctx context.Context, userID int64, token string, expiry time.Duration) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM magic_links WHERE user_id = $1
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		_, err := tx.ExecContext(ctx, query, userID)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			INSERT INTO magic_links (user_id, token, expires_at)
+			VALUES ($1, $2, $3)
+		`
+
+		_, err = tx.ExecContext(ctx, query, userID, token, time.Now().Add(expiry))
+		return err
+	})
+}
+
+// Redeem consumes the link and returns the ID of the user it was issued to.
+// A link can only be redeemed once, even when it turns out to be expired.
+func (s *MagicLinkStore) Redeem(ctx context.Context, token string) (int64, error) {
+	query := `
+		DELETE FROM magic_links WHERE token = $1
+		RETURNING user_id, expires_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var userID int64
+	var expiresAt time.Time
+	err := s.db.QueryRowContext(ctx, query, token).Scan(&userID, &expiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	if time.Now().After(expiresAt) {
+		return 0, ErrMagicLinkExpired
+	}
+
+	return userID, nil
+}
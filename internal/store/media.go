@@ -0,0 +1,190 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type MediaStore struct {
+	db *sql.DB
+}
+
+func NewMediaStore(db *sql.DB) *MediaStore {
+	return &MediaStore{db: db}
+}
+
+// Media is an uploaded image. The blobs live in the configured storage
+// under Key and VariantKeys; URLs are signed per response.
+type Media struct {
+	ID          int64             `json:"id" example:"1"`
+	UserID      int64             `json:"user_id" example:"1"`
+	Key         string            `json:"-"`
+	VariantKeys []string          `json:"-"`
+	ContentType string            `json:"content_type" example:"image/jpeg"`
+	Size        int64             `json:"size" example:"482113"`
+	Width       int               `json:"width" example:"1920"`
+	Height      int               `json:"height" example:"1080"`
+	CreatedAt   time.Time         `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	URLs        map[string]string `json:"urls,omitempty"`
+}
+
+// Keys maps "original" and the variant names to their storage keys.
+func (m *Media) Keys() map[string]string {
+	keys := map[string]string{"original": m.Key}
+	for _, key := range m.VariantKeys {
+		name := path.Base(key)
+		keys[strings.TrimSuffix(name, path.Ext(name))] = key
+	}
+
+	return keys
+}
+
+func (s *MediaStore) Create(ctx context.Context, media *Media) error {
+	query := `
+		INSERT INTO media (user_id, storage_key, variant_keys, content_type, size, width, height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	row := s.db.QueryRowContext(ctx, query, media.UserID, media.Key, pq.Array(media.VariantKeys), media.ContentType, media.Size, media.Width, media.Height)
+	return row.Scan(&media.ID, &media.CreatedAt)
+}
+
+func (s *MediaStore) Read(ctx context.Context, id int64) (*Media, error) {
+	query := `
+		SELECT id, user_id, storage_key, variant_keys, content_type, size, width, height, created_at
+		FROM media
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return scanMedia(s.db.QueryRowContext(ctx, query, id))
+}
+
+// CanView reports whether the viewer may download the media: it is a
+// current avatar, the viewer uploaded it, or it is attached to a published
+// post the viewer can see. Anonymous viewers pass 0.
+func (s *MediaStore) CanView(ctx context.Context, id, viewerID int64) (bool, error) {
+	query := `
+		SELECT
+			m.user_id = $2 OR
+			EXISTS (SELECT 1 FROM users au WHERE au.avatar_media_id = m.id AND au.deactivated_at IS NULL) OR
+			EXISTS (
+				SELECT 1
+				FROM post_media pm
+				JOIN posts p ON p.id = pm.post_id
+				JOIN users u ON u.id = p.user_id
+				WHERE pm.media_id = m.id AND p.status = 'published' AND ` + visibleToClause("u", "$2") + `
+			)
+		FROM media m
+		WHERE m.id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, query, id, viewerID).Scan(&allowed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	return allowed, err
+}
+
+// ReadByPostID returns the media attached to a post in display order.
+func (s *MediaStore) ReadByPostID(ctx context.Context, postID int64) ([]Media, error) {
+	query := `
+		SELECT m.id, m.user_id, m.storage_key, m.variant_keys, m.content_type, m.size, m.width, m.height, m.created_at
+		FROM media m
+		JOIN post_media pm ON pm.media_id = m.id
+		WHERE pm.post_id = $1
+		ORDER BY pm.position
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	media := []Media{}
+	for rows.Next() {
+		m, err := scanMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		media = append(media, *m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// Delete removes the user's media and returns it so the caller can delete
+// the blobs.
+func (s *MediaStore) Delete(ctx context.Context, id, userID int64) (*Media, error) {
+	query := `
+		DELETE FROM media
+		WHERE id = $1 AND user_id = $2
+		RETURNING id, user_id, storage_key, variant_keys, content_type, size, width, height, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return scanMedia(s.db.QueryRowContext(ctx, query, id, userID))
+}
+
+func scanMedia(row rowScanner) (*Media, error) {
+	var m Media
+	err := row.Scan(&m.ID, &m.UserID, &m.Key, pq.Array(&m.VariantKeys), &m.ContentType, &m.Size, &m.Width, &m.Height, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// attachPostMedia links media owned by userID to a post, keeping the given
+// order. It fails with ErrNotFound when any of the media is not the user's.
+func attachPostMedia(ctx context.Context, tx *sql.Tx, postID, userID int64, mediaIDs []int64) error {
+	query := `
+		INSERT INTO post_media (post_id, media_id, position)
+		SELECT $1, m.id, ids.position
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS ids(id, position)
+		JOIN media m ON m.id = ids.id AND m.user_id = $3
+	`
+
+	result, err := tx.ExecContext(ctx, query, postID, pq.Array(mediaIDs), userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected != int64(len(mediaIDs)) {
+		return ErrNotFound
+	}
+
+	return nil
+}
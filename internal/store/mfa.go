@@ -0,0 +1,203 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type MFAStore struct {
+	db *sql.DB
+}
+
+func NewMFAStore(db *sql.DB) *MFAStore {
+	return &MFAStore{db: db}
+}
+
+type TOTP struct {
+	UserID       int64
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+	ConfirmedAt  *time.Time
+	CreatedAt    time.Time
+}
+
+func (s *MFAStore) ReadTOTP(ctx context.Context, userID int64) (*TOTP, error) {
+	query := `
+		SELECT user_id, secret, enabled, last_used_step, confirmed_at, created_at
+		FROM user_totp
+		WHERE user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var totp TOTP
+	var confirmedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&totp.UserID, &totp.Secret, &totp.Enabled, &totp.LastUsedStep, &confirmedAt, &totp.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if confirmedAt.Valid {
+		totp.ConfirmedAt = &confirmedAt.Time
+	}
+
+	return &totp, nil
+}
+
+// CreateTOTP stores a pending secret for the user, replacing any earlier
+// unconfirmed enrollment. It fails with ErrMFAAlreadyEnabled when 2FA is
+// already active.
+func (s *MFAStore) CreateTOTP(ctx context.Context, userID int64, secret string) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+			SET secret = EXCLUDED.secret, last_used_step = 0, created_at = now()
+			WHERE user_totp.enabled = false
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+// EnableTOTP confirms the pending enrollment and replaces the user's recovery
+// codes with the given hashed codes.
+func (s *MFAStore) EnableTOTP(ctx context.Context, userID int64, step int64, recoveryCodes []string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE user_totp
+			SET enabled = true, confirmed_at = now(), last_used_step = $2
+			WHERE user_id = $1 AND enabled = false
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		result, err := tx.ExecContext(ctx, query, userID, step)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return ErrMFAAlreadyEnabled
+		}
+
+		return replaceRecoveryCodes(ctx, tx, userID, recoveryCodes)
+	})
+}
+
+func (s *MFAStore) DisableTOTP(ctx context.Context, userID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM user_totp WHERE user_id = $1
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		_, err := tx.ExecContext(ctx, query, userID)
+		if err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(ctx, tx, userID, nil)
+	})
+}
+
+// UseTOTPStep records that the code for the given time step was used. Codes
+// for the same or an earlier step are rejected with ErrNotFound so a code
+// cannot be replayed within its validity window.
+func (s *MFAStore) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	query := `
+		UPDATE user_totp SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *MFAStore) UseRecoveryCode(ctx context.Context, userID int64, code string) error {
+	query := `
+		UPDATE mfa_recovery_codes SET used_at = now()
+		WHERE user_id = $1 AND code = $2 AND used_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, userID, code)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int64, codes []string) error {
+	query := `
+		DELETE FROM mfa_recovery_codes WHERE user_id = $1
+	`
+
+	_, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO mfa_recovery_codes (user_id, code)
+		VALUES ($1, $2)
+	`
+
+	for _, code := range codes {
+		if _, err := tx.ExecContext(ctx, query, userID, code); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
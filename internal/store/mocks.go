@@ -10,12 +10,18 @@ import (
 
 func NewMockStore() *Store {
 	return &Store{
-		Users: &MockUserStore{},
+		Users:         &MockUserStore{},
+		Identities:    &MockIdentityStore{},
+		MFA:           &MockMFAStore{},
+		Sessions:      &MockSessionStore{},
+		RefreshTokens: &MockRefreshTokenStore{},
 	}
 }
 
 type MockUserStore struct {
 	ReadByIDFunc func(ctx context.Context, id int64) (*User, error)
+	FollowFunc   func(ctx context.Context, userID, followerID int64) (bool, error)
+	UnfollowFunc func(ctx context.Context, userID, followerID int64) error
 }
 
 func (m *MockUserStore) Create(ctx context.Context, tx *sql.Tx, user *User) (*User, error) {
@@ -23,6 +29,9 @@ func (m *MockUserStore) Create(ctx context.Context, tx *sql.Tx, user *User) (*Us
 }
 
 func (m *MockUserStore) ReadByID(ctx context.Context, id int64) (*User, error) {
+	if m.ReadByIDFunc != nil {
+		return m.ReadByIDFunc(ctx, id)
+	}
 	return &User{
 		ID: id,
 	}, nil
@@ -32,14 +41,40 @@ func (m *MockUserStore) ReadByEmail(ctx context.Context, email string) (*User, e
 	return nil, nil
 }
 
-func (m *MockUserStore) Follow(ctx context.Context, userID, followerID int64) error {
-	return nil
+func (m *MockUserStore) Follow(ctx context.Context, userID, followerID int64) (bool, error) {
+	if m.FollowFunc != nil {
+		return m.FollowFunc(ctx, userID, followerID)
+	}
+	return false, nil
 }
 
 func (m *MockUserStore) Unfollow(ctx context.Context, userID, followerID int64) error {
+	if m.UnfollowFunc != nil {
+		return m.UnfollowFunc(ctx, userID, followerID)
+	}
 	return nil
 }
 
+func (m *MockUserStore) CanViewPosts(ctx context.Context, ownerID, viewerID int64) (bool, error) {
+	return true, nil
+}
+
+func (m *MockUserStore) ReadStats(ctx context.Context, userID int64) (*UserStats, error) {
+	return &UserStats{}, nil
+}
+
+func (m *MockUserStore) ReadRelationship(ctx context.Context, userID, viewerID int64) (*Relationship, error) {
+	return &Relationship{}, nil
+}
+
+func (m *MockUserStore) ReadFollowers(ctx context.Context, userID, viewerID int64, page utils.PaginationQuery) ([]Connection, error) {
+	return []Connection{}, nil
+}
+
+func (m *MockUserStore) ReadFollowing(ctx context.Context, userID, viewerID int64, page utils.PaginationQuery) ([]Connection, error) {
+	return []Connection{}, nil
+}
+
 func (m *MockUserStore) ReadFeed(ctx context.Context, userID int64, fq utils.FeedQuery) ([]UserFeed, error) {
 	return nil, nil
 }
@@ -52,6 +87,207 @@ func (m *MockUserStore) Activate(ctx context.Context, userID int64, token string
 	return nil
 }
 
+func (m *MockUserStore) RotateInvitation(ctx context.Context, email, token string, invitationExpiry, cooldown time.Duration) (*User, error) {
+	return &User{}, nil
+}
+
+func (m *MockUserStore) PurgeUnactivated(ctx context.Context, grace time.Duration) (int64, int64, error) {
+	return 0, 0, nil
+}
+
+func (m *MockUserStore) CreateWithIdentity(ctx context.Context, user *User, identity *Identity) error {
+	return nil
+}
+
+func (m *MockUserStore) UpdateProfile(ctx context.Context, user *User) error {
+	return nil
+}
+
+func (m *MockUserStore) SetAvatar(ctx context.Context, user *User, mediaID int64) error {
+	return nil
+}
+
+func (m *MockUserStore) UpdatePassword(ctx context.Context, user *User) error {
+	return nil
+}
+
+func (m *MockUserStore) RehashPassword(ctx context.Context, user *User) error {
+	return nil
+}
+
+func (m *MockUserStore) CompareDummyPassword(plaintext string) {}
+
 func (m *MockUserStore) Delete(ctx context.Context, id int64) error {
 	return nil
 }
+
+func (m *MockUserStore) Deactivate(ctx context.Context, id int64) (time.Time, error) {
+	return time.Now(), nil
+}
+
+func (m *MockUserStore) Restore(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (m *MockUserStore) PurgeDeactivated(ctx context.Context, restoreWindow time.Duration, policy PurgePolicy) ([]int64, []string, error) {
+	return nil, nil, nil
+}
+
+func (m *MockUserStore) ReadTokenGeneration(ctx context.Context, id int64) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockUserStore) IncrementTokenGeneration(ctx context.Context, id int64) (int64, error) {
+	return 1, nil
+}
+
+func (m *MockUserStore) CreatePasswordReset(ctx context.Context, userID int64, token string, expiry time.Duration) error {
+	return nil
+}
+
+func (m *MockUserStore) ReadPasswordReset(ctx context.Context, token string) (int64, error) {
+	return 0, ErrNotFound
+}
+
+func (m *MockUserStore) ResetPassword(ctx context.Context, token string, user *User) error {
+	return nil
+}
+
+// MockIdentityStore keeps identities and pending authorizations in memory so
+// an OIDC flow can run end to end.
+type MockIdentityStore struct {
+	Identities   []Identity
+	AuthRequests map[string]*OIDCAuthRequest
+}
+
+func (m *MockIdentityStore) Create(ctx context.Context, identity *Identity) error {
+	for _, existing := range m.Identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return ErrIdentityAlreadyLinked
+		}
+	}
+
+	identity.ID = int64(len(m.Identities) + 1)
+	identity.CreatedAt = time.Now()
+	m.Identities = append(m.Identities, *identity)
+	return nil
+}
+
+func (m *MockIdentityStore) ReadByProviderSubject(ctx context.Context, provider, subject string) (*Identity, error) {
+	for _, identity := range m.Identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockIdentityStore) ReadByUserID(ctx context.Context, userID int64) ([]Identity, error) {
+	identities := []Identity{}
+	for _, identity := range m.Identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (m *MockIdentityStore) Delete(ctx context.Context, id, userID int64) error {
+	return nil
+}
+
+func (m *MockIdentityStore) CreateAuthRequest(ctx context.Context, req *OIDCAuthRequest) error {
+	if m.AuthRequests == nil {
+		m.AuthRequests = make(map[string]*OIDCAuthRequest)
+	}
+	m.AuthRequests[req.State] = req
+	return nil
+}
+
+func (m *MockIdentityStore) ConsumeAuthRequest(ctx context.Context, state string) (*OIDCAuthRequest, error) {
+	req, ok := m.AuthRequests[state]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	delete(m.AuthRequests, state)
+
+	if time.Now().After(req.ExpiresAt) {
+		return nil, ErrOIDCAuthRequestExpired
+	}
+	return req, nil
+}
+
+type MockMFAStore struct{}
+
+func (m *MockMFAStore) ReadTOTP(ctx context.Context, userID int64) (*TOTP, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockMFAStore) CreateTOTP(ctx context.Context, userID int64, secret string) error {
+	return nil
+}
+
+func (m *MockMFAStore) EnableTOTP(ctx context.Context, userID int64, step int64, recoveryCodes []string) error {
+	return nil
+}
+
+func (m *MockMFAStore) DisableTOTP(ctx context.Context, userID int64) error {
+	return nil
+}
+
+func (m *MockMFAStore) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	return nil
+}
+
+func (m *MockMFAStore) UseRecoveryCode(ctx context.Context, userID int64, code string) error {
+	return ErrNotFound
+}
+
+type MockSessionStore struct{}
+
+func (m *MockSessionStore) Create(ctx context.Context, session *Session) error {
+	session.CreatedAt = time.Now()
+	return nil
+}
+
+func (m *MockSessionStore) ReadByUserID(ctx context.Context, userID int64, activeWithin time.Duration) ([]Session, error) {
+	return []Session{}, nil
+}
+
+func (m *MockSessionStore) IsKnownDevice(ctx context.Context, userID int64, userAgent string) (bool, error) {
+	return true, nil
+}
+
+func (m *MockSessionStore) Touch(ctx context.Context, id, ip string) error {
+	return nil
+}
+
+func (m *MockSessionStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+
+func (m *MockSessionStore) Revoke(ctx context.Context, id string, userID int64) error {
+	return nil
+}
+
+func (m *MockSessionStore) RevokeByUser(ctx context.Context, userID int64) error {
+	return nil
+}
+
+type MockRefreshTokenStore struct{}
+
+func (m *MockRefreshTokenStore) Create(ctx context.Context, token *RefreshToken) error {
+	return nil
+}
+
+func (m *MockRefreshTokenStore) Rotate(ctx context.Context, token string, next *RefreshToken) error {
+	return nil
+}
+
+func (m *MockRefreshTokenStore) RevokeByToken(ctx context.Context, userID int64, token string) error {
+	return nil
+}
+
+func (m *MockRefreshTokenStore) RevokeByUser(ctx context.Context, userID int64) error {
+	return nil
+}
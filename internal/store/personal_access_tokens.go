@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PersonalAccessTokenStore struct {
+	db *sql.DB
+}
+
+func NewPersonalAccessTokenStore(db *sql.DB) *PersonalAccessTokenStore {
+	return &PersonalAccessTokenStore{db: db}
+}
+
+type PersonalAccessToken struct {
+	ID         int64      `json:"id" example:"1"`
+	UserID     int64      `json:"user_id" example:"1"`
+	Name       string     `json:"name" example:"deploy bot"`
+	Token      string     `json:"-"`
+	Scopes     []string   `json:"scopes" example:"posts:write,feed:read"`
+	ExpiresAt  *time.Time `json:"expires_at" example:"2021-01-01T00:00:00Z"`
+	LastUsedAt *time.Time `json:"last_used_at" example:"2021-01-01T00:00:00Z"`
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at" example:"2021-01-01T00:00:00Z"`
+}
+
+func (s *PersonalAccessTokenStore) Create(ctx context.Context, token *PersonalAccessToken) error {
+	query := `
+		INSERT INTO personal_access_tokens (user_id, name, token, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	row := s.db.QueryRowContext(ctx, query, token.UserID, token.Name, token.Token, pq.Array(token.Scopes), token.ExpiresAt)
+	return row.Scan(&token.ID, &token.CreatedAt)
+}
+
+func (s *PersonalAccessTokenStore) ReadByToken(ctx context.Context, token string) (*PersonalAccessToken, error) {
+	query := `
+		SELECT id, user_id, name, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM personal_access_tokens
+		WHERE token = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	row := s.db.QueryRowContext(ctx, query, token)
+
+	pat, err := scanPersonalAccessToken(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return pat, nil
+}
+
+func (s *PersonalAccessTokenStore) ReadByUserID(ctx context.Context, userID int64) ([]PersonalAccessToken, error) {
+	query := `
+		SELECT id, user_id, name, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []PersonalAccessToken{}
+	for rows.Next() {
+		pat, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *pat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s *PersonalAccessTokenStore) Revoke(ctx context.Context, id, userID int64) error {
+	query := `
+		UPDATE personal_access_tokens SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeByUser revokes every active token of the user.
+func (s *PersonalAccessTokenStore) RevokeByUser(ctx context.Context, userID int64) error {
+	query := `
+		UPDATE personal_access_tokens SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// Touch records that the token was just used. Writes are throttled to one a
+// minute per token to keep busy automation from hammering the table.
+func (s *PersonalAccessTokenStore) Touch(ctx context.Context, id int64) error {
+	query := `
+		UPDATE personal_access_tokens SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPersonalAccessToken(row rowScanner) (*PersonalAccessToken, error) {
+	var pat PersonalAccessToken
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&pat.ID, &pat.UserID, &pat.Name, pq.Array(&pat.Scopes), &expiresAt, &lastUsedAt, &revokedAt, &pat.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		pat.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		pat.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		pat.RevokedAt = &revokedAt.Time
+	}
+
+	return &pat, nil
+}
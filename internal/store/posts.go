@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/andras-szesztai/social/internal/utils"
 	"github.com/lib/pq"
 )
 
@@ -16,6 +17,14 @@ func NewPostStore(db *sql.DB) *PostStore {
 	return &PostStore{db: db}
 }
 
+// Post statuses. Drafts and scheduled posts are only visible to their
+// author until they are published.
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+)
+
 type Post struct {
 	ID        int64     `json:"id"`
 	Title     string    `json:"title"`
@@ -25,21 +34,54 @@ type Post struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Version   int64     `json:"-"`
+	MediaIDs  []int64   `json:"-"`
+	Media     []Media   `json:"media,omitempty"`
+	Status    string    `json:"status" example:"published"`
+	// PublishAt is when a scheduled post is due to be published.
+	PublishAt   *time.Time `json:"publish_at,omitempty" example:"2021-01-01T00:00:00Z"`
+	PublishedAt *time.Time `json:"published_at,omitempty" example:"2021-01-01T00:00:00Z"`
+	// Edited reports whether the post was changed after it was published,
+	// EditedAt when that last happened.
+	Edited   bool       `json:"edited" example:"false"`
+	EditedAt *time.Time `json:"edited_at,omitempty" example:"2021-01-01T00:00:00Z"`
+	// QuotedPostID is the post this one quotes. It is cleared when the
+	// quoted post is deleted.
+	QuotedPostID *int64 `json:"quoted_post_id,omitempty" example:"1"`
+	RepostCount  int64  `json:"repost_count" example:"3"`
+	// Reactions and MyReactions are only filled in when the post is read
+	// on behalf of a viewer.
+	Reactions   ReactionCounts `json:"reactions,omitempty" swaggertype:"object,integer" example:"like:3"`
+	MyReactions []string       `json:"my_reactions,omitempty" example:"like"`
 }
 
 func (s *PostStore) Create(ctx context.Context, post *Post) (*Post, error) {
 	query := `
-		INSERT INTO posts (title, content, user_id, tags)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at, updated_at
+		INSERT INTO posts (title, content, user_id, tags, quoted_post_id, status, publish_at, published_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, CASE WHEN $6 = 'published' THEN now() END)
+		RETURNING id, created_at, updated_at, published_at
 	`
 
+	if post.Status == "" {
+		post.Status = PostStatusPublished
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	row := s.db.QueryRowContext(ctx, query, post.Title, post.Content, post.UserID, pq.Array(post.Tags))
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, query, post.Title, post.Content, post.UserID, pq.Array(post.Tags), post.QuotedPostID, post.Status, post.PublishAt)
+		if err := row.Scan(&post.ID, &post.CreatedAt, &post.UpdatedAt, &post.PublishedAt); err != nil {
+			if pgErrorCode(err) == pgForeignKeyViolation {
+				return ErrNotFound
+			}
+			return err
+		}
 
-	err := row.Scan(&post.ID, &post.CreatedAt, &post.UpdatedAt)
+		if len(post.MediaIDs) == 0 {
+			return nil
+		}
+		return attachPostMedia(ctx, tx, post.ID, post.UserID, post.MediaIDs)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +91,8 @@ func (s *PostStore) Create(ctx context.Context, post *Post) (*Post, error) {
 
 func (s *PostStore) Read(ctx context.Context, id int64) (*Post, error) {
 	query := `
-		SELECT id, title, content, user_id, tags, created_at, updated_at, version
+		SELECT id, title, content, user_id, tags, created_at, updated_at, version, quoted_post_id, status, publish_at, published_at,
+			(SELECT COUNT(*) FROM reposts WHERE post_id = posts.id), ` + editedAtColumn("posts") + `
 		FROM posts
 		WHERE id = $1
 	`
@@ -60,31 +103,63 @@ func (s *PostStore) Read(ctx context.Context, id int64) (*Post, error) {
 	row := s.db.QueryRowContext(ctx, query, id)
 
 	var post Post
-	err := row.Scan(&post.ID, &post.Title, &post.Content, &post.UserID, pq.Array(&post.Tags), &post.CreatedAt, &post.UpdatedAt, &post.Version)
+	err := row.Scan(
+		&post.ID, &post.Title, &post.Content, &post.UserID, pq.Array(&post.Tags), &post.CreatedAt, &post.UpdatedAt, &post.Version, &post.QuotedPostID,
+		&post.Status, &post.PublishAt, &post.PublishedAt, &post.RepostCount, &post.EditedAt,
+	)
 	if err != nil {
 		return nil, err
 	}
+	post.Edited = post.EditedAt != nil
 
 	return &post, nil
 }
 
+// Update saves the post if it is still at post.Version and keeps the
+// previous version as a revision. It returns sql.ErrNoRows when the post was
+// changed or deleted in the meantime.
 func (s *PostStore) Update(ctx context.Context, post *Post) (*Post, error) {
-	query := `
-		UPDATE posts
-		SET title = $1, content = $2, tags = $3, updated_at = now(), version = version + 1
-		WHERE id = $4 AND version = $5
-		RETURNING updated_at, version
-	`
-
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	row := s.db.QueryRowContext(ctx, query, post.Title, post.Content, pq.Array(post.Tags), post.ID, post.Version)
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO post_revisions (post_id, version, title, content, tags, created_at)
+			SELECT id, version, title, content, tags, updated_at
+			FROM posts
+			WHERE id = $1 AND version = $2
+			FOR UPDATE
+		`
+
+		result, err := tx.ExecContext(ctx, query, post.ID, post.Version)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return sql.ErrNoRows
+		}
+
+		query = `
+			UPDATE posts
+			SET title = $1, content = $2, tags = $3, status = $6, publish_at = $7,
+				published_at = CASE WHEN $6 = 'published' THEN COALESCE(published_at, now()) END,
+				updated_at = now(), version = version + 1
+			WHERE id = $4 AND version = $5
+			RETURNING updated_at, version, published_at, ` + editedAtColumn("posts") + `
+		`
 
-	err := row.Scan(&post.UpdatedAt, &post.Version)
+		row := tx.QueryRowContext(ctx, query, post.Title, post.Content, pq.Array(post.Tags), post.ID, post.Version, post.Status, post.PublishAt)
+		return row.Scan(&post.UpdatedAt, &post.Version, &post.PublishedAt, &post.EditedAt)
+	})
 	if err != nil {
 		return nil, err
 	}
+	post.Edited = post.EditedAt != nil
 
 	return post, nil
 }
@@ -113,3 +188,179 @@ func (s *PostStore) Delete(ctx context.Context, id int64) error {
 
 	return nil
 }
+
+// ReadDrafts lists userID's drafts and scheduled posts, scheduled ones first
+// by when they are due, then drafts by when they were last edited.
+func (s *PostStore) ReadDrafts(ctx context.Context, userID int64, page utils.PaginationQuery) ([]Post, error) {
+	query := `
+		SELECT id, title, content, user_id, tags, created_at, updated_at, quoted_post_id, status, publish_at
+		FROM posts
+		WHERE user_id = $1 AND status <> 'published'
+		ORDER BY publish_at NULLS LAST, updated_at DESC, id DESC
+		OFFSET $2 LIMIT $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID, page.Offset, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []Post{}
+	for rows.Next() {
+		var post Post
+		err := rows.Scan(
+			&post.ID, &post.Title, &post.Content, &post.UserID, pq.Array(&post.Tags), &post.CreatedAt, &post.UpdatedAt, &post.QuotedPostID,
+			&post.Status, &post.PublishAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// PublishDue publishes up to limit scheduled posts whose time has come and
+// returns their IDs. Rows another process is publishing are skipped, so
+// several API replicas can run the scheduler at once.
+func (s *PostStore) PublishDue(ctx context.Context, limit int) ([]int64, error) {
+	query := `
+		WITH due AS (
+			SELECT id FROM posts
+			WHERE status = 'scheduled' AND publish_at <= now()
+			ORDER BY publish_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE posts p
+		SET status = 'published', published_at = now(), updated_at = now(), version = p.version + 1
+		FROM due
+		WHERE p.id = due.id
+		RETURNING p.id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// PostRevision is an earlier version of a post, kept when it was edited.
+type PostRevision struct {
+	PostID  int64    `json:"post_id" example:"1"`
+	Version int64    `json:"version" example:"0"`
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+	// CreatedAt is when this version was saved, RevisedAt when it was
+	// replaced by the next one.
+	CreatedAt time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	RevisedAt time.Time `json:"revised_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// editedAtColumn selects when the post aliased as post was last edited
+// after being published, or NULL.
+func editedAtColumn(post string) string {
+	return `(
+		SELECT MAX(pr.revised_at) FROM post_revisions pr
+		WHERE pr.post_id = ` + post + `.id AND pr.revised_at > ` + post + `.published_at
+	)`
+}
+
+// revisionVisibleClause restricts post_revisions to versions saved after the
+// post was published when $2 is true. Drafts stay private to whoever may
+// edit the post.
+const revisionVisibleClause = `(
+	NOT $2 OR created_at >= (SELECT published_at FROM posts WHERE id = post_revisions.post_id)
+)`
+
+// ReadRevisions lists the post's earlier versions, most recent first. With
+// publishedOnly it leaves out versions from before the post was published.
+func (s *PostStore) ReadRevisions(ctx context.Context, postID int64, publishedOnly bool, page utils.PaginationQuery) ([]PostRevision, error) {
+	query := `
+		SELECT post_id, version, title, content, tags, created_at, revised_at
+		FROM post_revisions
+		WHERE post_id = $1 AND ` + revisionVisibleClause + `
+		ORDER BY version DESC
+		OFFSET $3 LIMIT $4
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, postID, publishedOnly, page.Offset, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []PostRevision{}
+	for rows.Next() {
+		revision, err := scanPostRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// ReadRevision reads one earlier version. With publishedOnly a version from
+// before the post was published is not found.
+func (s *PostStore) ReadRevision(ctx context.Context, postID int64, publishedOnly bool, version int64) (*PostRevision, error) {
+	query := `
+		SELECT post_id, version, title, content, tags, created_at, revised_at
+		FROM post_revisions
+		WHERE post_id = $1 AND ` + revisionVisibleClause + ` AND version = $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return scanPostRevision(s.db.QueryRowContext(ctx, query, postID, publishedOnly, version))
+}
+
+func scanPostRevision(row rowScanner) (*PostRevision, error) {
+	var revision PostRevision
+	err := row.Scan(
+		&revision.PostID, &revision.Version, &revision.Title, &revision.Content, pq.Array(&revision.Tags), &revision.CreatedAt, &revision.RevisedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
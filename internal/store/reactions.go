@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/andras-szesztai/social/internal/utils"
+	"github.com/lib/pq"
+)
+
+type ReactionStore struct {
+	db *sql.DB
+}
+
+func NewReactionStore(db *sql.DB) *ReactionStore {
+	return &ReactionStore{db: db}
+}
+
+// Reaction is an entry of a post's reaction list.
+type Reaction struct {
+	PublicUser
+	Type      string    `json:"type" example:"like"`
+	ReactedAt time.Time `json:"reacted_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// ReactionCounts holds how many users reacted to a post, by reaction type.
+// Types nobody used are left out.
+type ReactionCounts map[string]int64
+
+// Scan reads the JSON object built by reactionColumns.
+func (c *ReactionCounts) Scan(src any) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into ReactionCounts", src)
+	}
+
+	return json.Unmarshal(b, c)
+}
+
+// reactionColumns selects the reaction counts of the post and the types the
+// viewer reacted with, given SQL expressions for the post and viewer IDs.
+func reactionColumns(postID, viewerID string) string {
+	return fmt.Sprintf(`
+		(
+			SELECT COALESCE(jsonb_object_agg(rc.type, rc.count), '{}')
+			FROM (SELECT type, COUNT(*) AS count FROM post_reactions WHERE post_id = %[1]s GROUP BY type) rc
+		),
+		ARRAY(SELECT type FROM post_reactions WHERE post_id = %[1]s AND user_id = %[2]s ORDER BY type)
+	`, postID, viewerID)
+}
+
+// Add reacts to the post. A user can react with several types, but each
+// type only once. It returns ErrAlreadyReacted, ErrInvalidReaction, or
+// ErrNotFound when the post does not exist.
+func (s *ReactionStore) Add(ctx context.Context, postID, userID int64, reactionType string) error {
+	query := `
+		INSERT INTO post_reactions (post_id, user_id, type)
+		VALUES ($1, $2, $3)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, postID, userID, reactionType)
+	switch pgErrorCode(err) {
+	case pgUniqueViolation:
+		return ErrAlreadyReacted
+	case pgForeignKeyViolation:
+		return ErrNotFound
+	case pgCheckViolation:
+		return ErrInvalidReaction
+	}
+
+	return err
+}
+
+func (s *ReactionStore) Remove(ctx context.Context, postID, userID int64, reactionType string) error {
+	query := `
+		DELETE FROM post_reactions WHERE post_id = $1 AND user_id = $2 AND type = $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, postID, userID, reactionType)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotReacted
+	}
+
+	return nil
+}
+
+// ReadSummary returns the post's reaction counts and the types viewerID
+// reacted with.
+func (s *ReactionStore) ReadSummary(ctx context.Context, postID, viewerID int64) (ReactionCounts, []string, error) {
+	query := `SELECT ` + reactionColumns("$1", "$2")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var counts ReactionCounts
+	mine := []string{}
+	err := s.db.QueryRowContext(ctx, query, postID, viewerID).Scan(&counts, pq.Array(&mine))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return counts, mine, nil
+}
+
+// ReadByPostID lists who reacted to the post, most recent first. An empty
+// reactionType lists reactions of every type.
+func (s *ReactionStore) ReadByPostID(ctx context.Context, postID int64, reactionType string, page utils.PaginationQuery) ([]Reaction, error) {
+	query := `
+		SELECT u.id, u.username, u.created_at, u.display_name, u.bio, u.avatar_url, u.links, u.location, u.is_private, r.type, r.created_at
+		FROM post_reactions r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.post_id = $1 AND ($2 = '' OR r.type = $2) AND u.deactivated_at IS NULL
+		ORDER BY r.created_at DESC, u.id DESC
+		OFFSET $3 LIMIT $4
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, postID, reactionType, page.Offset, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := []Reaction{}
+	for rows.Next() {
+		var r Reaction
+		err := rows.Scan(&r.ID, &r.Username, &r.CreatedAt, &r.DisplayName, &r.Bio, &r.AvatarURL, pq.Array(&r.Links), &r.Location, &r.IsPrivate, &r.Type, &r.ReactedAt)
+		if err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reactions, nil
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RefreshTokenStore struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenStore(db *sql.DB) *RefreshTokenStore {
+	return &RefreshTokenStore{db: db}
+}
+
+type RefreshToken struct {
+	ID          int64
+	UserID      int64
+	FamilyID    string
+	Token       string
+	MFAVerified bool
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+func (s *RefreshTokenStore) Create(ctx context.Context, token *RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token, mfa_verified, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	row := s.db.QueryRowContext(ctx, query, token.UserID, token.FamilyID, token.Token, token.MFAVerified, token.ExpiresAt)
+	return row.Scan(&token.ID, &token.CreatedAt)
+}
+
+// Rotate exchanges the refresh token identified by token for next, which
+// inherits the user, family and MFA state of the original. Presenting a token that was
+// already rotated revokes every token in its family and returns
+// ErrRefreshTokenReused.
+func (s *RefreshTokenStore) Rotate(ctx context.Context, token string, next *RefreshToken) error {
+	reused := false
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT user_id, family_id, mfa_verified, expires_at, used_at, revoked_at
+			FROM refresh_tokens
+			WHERE token = $1
+			FOR UPDATE
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		var expiresAt time.Time
+		var usedAt, revokedAt sql.NullTime
+		row := tx.QueryRowContext(ctx, query, token)
+		err := row.Scan(&next.UserID, &next.FamilyID, &next.MFAVerified, &expiresAt, &usedAt, &revokedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if usedAt.Valid {
+			reused = true
+			return revokeFamily(ctx, tx, next.FamilyID)
+		}
+		if revokedAt.Valid {
+			return ErrRefreshTokenRevoked
+		}
+		if time.Now().After(expiresAt) {
+			return ErrRefreshTokenExpired
+		}
+
+		query = `
+			UPDATE refresh_tokens SET used_at = now() WHERE token = $1
+		`
+
+		_, err = tx.ExecContext(ctx, query, token)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			INSERT INTO refresh_tokens (user_id, family_id, token, mfa_verified, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at
+		`
+
+		row = tx.QueryRowContext(ctx, query, next.UserID, next.FamilyID, next.Token, next.MFAVerified, next.ExpiresAt)
+		return row.Scan(&next.ID, &next.CreatedAt)
+	})
+	if err != nil {
+		return err
+	}
+
+	if reused {
+		return ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+// RevokeByToken revokes the whole family the given refresh token belongs to,
+// provided the token was issued to userID.
+func (s *RefreshTokenStore) RevokeByToken(ctx context.Context, userID int64, token string) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token = $1 AND user_id = $2)
+			AND revoked_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, token, userID)
+	return err
+}
+
+func (s *RefreshTokenStore) RevokeByUser(ctx context.Context, userID int64) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+func revokeFamily(ctx context.Context, tx *sql.Tx, familyID string) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, familyID)
+	return err
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RepostStore struct {
+	db *sql.DB
+}
+
+func NewRepostStore(db *sql.DB) *RepostStore {
+	return &RepostStore{db: db}
+}
+
+// Create shares the post with userID's followers. It returns
+// ErrAlreadyReposted, or ErrNotFound when the post does not exist.
+func (s *RepostStore) Create(ctx context.Context, postID, userID int64) error {
+	query := `
+		INSERT INTO reposts (post_id, user_id)
+		VALUES ($1, $2)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, postID, userID)
+	switch pgErrorCode(err) {
+	case pgUniqueViolation:
+		return ErrAlreadyReposted
+	case pgForeignKeyViolation:
+		return ErrNotFound
+	}
+
+	return err
+}
+
+func (s *RepostStore) Delete(ctx context.Context, postID, userID int64) error {
+	query := `
+		DELETE FROM reposts WHERE post_id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, postID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotReposted
+	}
+
+	return nil
+}
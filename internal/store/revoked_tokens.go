@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RevokedTokenStore struct {
+	db *sql.DB
+}
+
+func NewRevokedTokenStore(db *sql.DB) *RevokedTokenStore {
+	return &RevokedTokenStore{db: db}
+}
+
+func (s *RevokedTokenStore) Revoke(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, jti, userID, expiresAt)
+	return err
+}
+
+func (s *RevokedTokenStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, query, jti).Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+// PurgeExpired deletes the revocations of tokens that have expired on their
+// own and reports how many were removed.
+func (s *RevokedTokenStore) PurgeExpired(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM revoked_tokens WHERE expires_at < now()
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type SessionStore struct {
+	db *sql.DB
+}
+
+func NewSessionStore(db *sql.DB) *SessionStore {
+	return &SessionStore{db: db}
+}
+
+// Session is a login on one device. Its ID is shared with the refresh token
+// family issued at login and the sid claim of its access tokens.
+type Session struct {
+	ID         string     `json:"id" example:"6f1c1b2e-3c4d-4e5f-8a9b-0c1d2e3f4a5b"`
+	UserID     int64      `json:"-"`
+	UserAgent  string     `json:"user_agent" example:"Mozilla/5.0"`
+	IP         string     `json:"ip" example:"203.0.113.7"`
+	CreatedAt  time.Time  `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	LastSeenAt time.Time  `json:"last_seen_at" example:"2021-01-01T00:00:00Z"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"current" example:"true"`
+}
+
+func (s *SessionStore) Create(ctx context.Context, session *Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, user_agent, ip)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, last_seen_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	row := s.db.QueryRowContext(ctx, query, session.ID, session.UserID, session.UserAgent, session.IP)
+	return row.Scan(&session.CreatedAt, &session.LastSeenAt)
+}
+
+// ReadByUserID lists the user's sessions that are not revoked and were seen
+// within activeWithin.
+func (s *SessionStore) ReadByUserID(ctx context.Context, userID int64, activeWithin time.Duration) ([]Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip, created_at, last_seen_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND last_seen_at > now() - make_interval(secs => $2)
+		ORDER BY last_seen_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID, activeWithin.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// IsKnownDevice reports whether the user signed in with the user agent
+// before. A user without any session has no device history to compare
+// against and every device counts as known.
+func (s *SessionStore) IsKnownDevice(ctx context.Context, userID int64, userAgent string) (bool, error) {
+	query := `
+		SELECT NOT EXISTS (SELECT 1 FROM sessions WHERE user_id = $1)
+			OR EXISTS (SELECT 1 FROM sessions WHERE user_id = $1 AND user_agent = $2)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var known bool
+	err := s.db.QueryRowContext(ctx, query, userID, userAgent).Scan(&known)
+	return known, err
+}
+
+// Touch records that the session was just used from ip. Writes are throttled
+// to one a minute per session since it runs on every authenticated request.
+func (s *SessionStore) Touch(ctx context.Context, id, ip string) error {
+	query := `
+		UPDATE sessions SET last_seen_at = now(), ip = $2
+		WHERE id = $1 AND (last_seen_at < now() - interval '1 minute' OR ip <> $2)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, id, ip)
+	return err
+}
+
+// IsRevoked reports whether the session was revoked. Sessions that no longer
+// exist count as revoked.
+func (s *SessionStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	query := `
+		SELECT revoked_at IS NOT NULL FROM sessions WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&revoked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+// Revoke ends the session together with its refresh token family.
+func (s *SessionStore) Revoke(ctx context.Context, id string, userID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE sessions SET revoked_at = now()
+			WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		result, err := tx.ExecContext(ctx, query, id, userID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		query = `
+			UPDATE refresh_tokens SET revoked_at = now()
+			WHERE family_id = $1 AND revoked_at IS NULL
+		`
+
+		_, err = tx.ExecContext(ctx, query, id)
+		return err
+	})
+}
+
+func (s *SessionStore) RevokeByUser(ctx context.Context, userID int64) error {
+	query := `
+		UPDATE sessions SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userID)
+	return err
+}
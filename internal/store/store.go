@@ -8,32 +8,105 @@ import (
 	"time"
 
 	"github.com/andras-szesztai/social/internal/utils"
+	"github.com/lib/pq"
 )
 
 var (
-	ErrEmailAlreadyExists    = errors.New("email already exists")
-	ErrUsernameAlreadyExists = errors.New("username already exists")
-	ErrNotFound              = errors.New("not found")
-	ErrInvitationExpired     = errors.New("invitation expired")
+	ErrEmailAlreadyExists     = errors.New("email already exists")
+	ErrUsernameAlreadyExists  = errors.New("username already exists")
+	ErrNotFound               = errors.New("not found")
+	ErrInvitationExpired      = errors.New("invitation expired")
+	ErrInvitationCooldown     = errors.New("invitation sent too recently")
+	ErrPasswordResetExpired   = errors.New("password reset expired")
+	ErrRefreshTokenExpired    = errors.New("refresh token expired")
+	ErrRefreshTokenReused     = errors.New("refresh token reused")
+	ErrRefreshTokenRevoked    = errors.New("refresh token revoked")
+	ErrMFAAlreadyEnabled      = errors.New("two-factor authentication already enabled")
+	ErrMagicLinkExpired       = errors.New("magic link expired")
+	ErrIdentityAlreadyLinked  = errors.New("identity already linked to an account")
+	ErrOIDCAuthRequestExpired = errors.New("sign in request expired")
+	ErrEmailChangeExpired     = errors.New("email change expired")
+	ErrSelfFollow             = errors.New("users cannot follow themselves")
+	ErrAlreadyFollowing       = errors.New("already following this user")
+	ErrNotFollowing           = errors.New("not following this user")
+	ErrFollowRequestPending   = errors.New("follow request already pending")
+	ErrBlocked                = errors.New("blocked")
+	ErrSelfBlock              = errors.New("users cannot block themselves")
+	ErrAlreadyBlocked         = errors.New("user already blocked")
+	ErrNotBlocked             = errors.New("user not blocked")
+	ErrSelfMute               = errors.New("users cannot mute themselves")
+	ErrAlreadyMuted           = errors.New("user already muted")
+	ErrNotMuted               = errors.New("user not muted")
+	ErrInvalidReaction        = errors.New("invalid reaction type")
+	ErrAlreadyReacted         = errors.New("already reacted to this post")
+	ErrNotReacted             = errors.New("not reacted to this post")
+	ErrAlreadyBookmarked      = errors.New("post already bookmarked")
+	ErrNotBookmarked          = errors.New("post not bookmarked")
+	ErrBookmarkFolderExists   = errors.New("bookmark folder already exists")
+	ErrAlreadyReposted        = errors.New("post already reposted")
+	ErrNotReposted            = errors.New("post not reposted")
 )
 
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+)
+
+// pgErrorCode returns the SQLSTATE code of a Postgres error, or an empty
+// string for other errors.
+func pgErrorCode(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+
+	return ""
+}
+
 type Store struct {
 	Users interface {
 		Create(ctx context.Context, tx *sql.Tx, user *User) (*User, error)
 		ReadByID(ctx context.Context, id int64) (*User, error)
 		ReadByEmail(ctx context.Context, email string) (*User, error)
-		Follow(ctx context.Context, userID, followerID int64) error
+		Follow(ctx context.Context, userID, followerID int64) (bool, error)
 		Unfollow(ctx context.Context, userID, followerID int64) error
+		CanViewPosts(ctx context.Context, ownerID, viewerID int64) (bool, error)
 		ReadFeed(ctx context.Context, userID int64, fq utils.FeedQuery) ([]UserFeed, error)
+		ReadStats(ctx context.Context, userID int64) (*UserStats, error)
+		ReadRelationship(ctx context.Context, userID, viewerID int64) (*Relationship, error)
+		ReadFollowers(ctx context.Context, userID, viewerID int64, page utils.PaginationQuery) ([]Connection, error)
+		ReadFollowing(ctx context.Context, userID, viewerID int64, page utils.PaginationQuery) ([]Connection, error)
 		CreateAndInvite(ctx context.Context, user *User, token string, invitationExpiry time.Duration) error
 		Activate(ctx context.Context, userID int64, token string) error
+		RotateInvitation(ctx context.Context, email, token string, invitationExpiry, cooldown time.Duration) (*User, error)
+		PurgeUnactivated(ctx context.Context, grace time.Duration) (int64, int64, error)
+		CreateWithIdentity(ctx context.Context, user *User, identity *Identity) error
 		Delete(ctx context.Context, id int64) error
+		Deactivate(ctx context.Context, id int64) (time.Time, error)
+		Restore(ctx context.Context, id int64) error
+		PurgeDeactivated(ctx context.Context, restoreWindow time.Duration, policy PurgePolicy) ([]int64, []string, error)
+		UpdateProfile(ctx context.Context, user *User) error
+		SetAvatar(ctx context.Context, user *User, mediaID int64) error
+		UpdatePassword(ctx context.Context, user *User) error
+		RehashPassword(ctx context.Context, user *User) error
+		CompareDummyPassword(plaintext string)
+		ReadTokenGeneration(ctx context.Context, id int64) (int64, error)
+		IncrementTokenGeneration(ctx context.Context, id int64) (int64, error)
+		CreatePasswordReset(ctx context.Context, userID int64, token string, expiry time.Duration) error
+		ReadPasswordReset(ctx context.Context, token string) (int64, error)
+		ResetPassword(ctx context.Context, token string, user *User) error
 	}
 	Posts interface {
 		Create(ctx context.Context, post *Post) (*Post, error)
 		Read(ctx context.Context, id int64) (*Post, error)
 		Update(ctx context.Context, post *Post) (*Post, error)
 		Delete(ctx context.Context, id int64) error
+		ReadDrafts(ctx context.Context, userID int64, page utils.PaginationQuery) ([]Post, error)
+		PublishDue(ctx context.Context, limit int) ([]int64, error)
+		ReadRevisions(ctx context.Context, postID int64, publishedOnly bool, page utils.PaginationQuery) ([]PostRevision, error)
+		ReadRevision(ctx context.Context, postID int64, publishedOnly bool, version int64) (*PostRevision, error)
 	}
 	Comments interface {
 		Create(ctx context.Context, comment *Comment) (*Comment, error)
@@ -45,14 +118,130 @@ type Store struct {
 	Roles interface {
 		ReadByName(ctx context.Context, name string) (*Role, error)
 	}
+	RefreshTokens interface {
+		Create(ctx context.Context, token *RefreshToken) error
+		Rotate(ctx context.Context, token string, next *RefreshToken) error
+		RevokeByToken(ctx context.Context, userID int64, token string) error
+		RevokeByUser(ctx context.Context, userID int64) error
+	}
+	MFA interface {
+		ReadTOTP(ctx context.Context, userID int64) (*TOTP, error)
+		CreateTOTP(ctx context.Context, userID int64, secret string) error
+		EnableTOTP(ctx context.Context, userID int64, step int64, recoveryCodes []string) error
+		DisableTOTP(ctx context.Context, userID int64) error
+		UseTOTPStep(ctx context.Context, userID int64, step int64) error
+		UseRecoveryCode(ctx context.Context, userID int64, code string) error
+	}
+	PersonalAccessTokens interface {
+		Create(ctx context.Context, token *PersonalAccessToken) error
+		ReadByToken(ctx context.Context, token string) (*PersonalAccessToken, error)
+		ReadByUserID(ctx context.Context, userID int64) ([]PersonalAccessToken, error)
+		Revoke(ctx context.Context, id, userID int64) error
+		RevokeByUser(ctx context.Context, userID int64) error
+		Touch(ctx context.Context, id int64) error
+	}
+	LoginFailures interface {
+		Read(ctx context.Context, scope, subject string) (*LoginFailure, error)
+		RecordFailure(ctx context.Context, scope, subject string, resetAfter time.Duration) (*LoginFailure, error)
+		Lock(ctx context.Context, id int64, until time.Time) error
+		Reset(ctx context.Context, scope, subject string) error
+		ReadLocked(ctx context.Context) ([]LoginFailure, error)
+		Delete(ctx context.Context, id int64) error
+	}
+	MagicLinks interface {
+		Create(ctx context.Context, userID int64, token string, expiry time.Duration) error
+		Redeem(ctx context.Context, token string) (int64, error)
+	}
+	Identities interface {
+		Create(ctx context.Context, identity *Identity) error
+		ReadByProviderSubject(ctx context.Context, provider, subject string) (*Identity, error)
+		ReadByUserID(ctx context.Context, userID int64) ([]Identity, error)
+		Delete(ctx context.Context, id, userID int64) error
+		CreateAuthRequest(ctx context.Context, req *OIDCAuthRequest) error
+		ConsumeAuthRequest(ctx context.Context, state string) (*OIDCAuthRequest, error)
+	}
+	Sessions interface {
+		Create(ctx context.Context, session *Session) error
+		ReadByUserID(ctx context.Context, userID int64, activeWithin time.Duration) ([]Session, error)
+		IsKnownDevice(ctx context.Context, userID int64, userAgent string) (bool, error)
+		Touch(ctx context.Context, id, ip string) error
+		IsRevoked(ctx context.Context, id string) (bool, error)
+		Revoke(ctx context.Context, id string, userID int64) error
+		RevokeByUser(ctx context.Context, userID int64) error
+	}
+	EmailChanges interface {
+		Create(ctx context.Context, change *EmailChange, token string, expiry time.Duration) error
+		Confirm(ctx context.Context, token, revertToken string, revertWindow time.Duration) (*EmailChange, error)
+		Revert(ctx context.Context, revertToken string) (*EmailChange, error)
+	}
+	Media interface {
+		Create(ctx context.Context, media *Media) error
+		Read(ctx context.Context, id int64) (*Media, error)
+		CanView(ctx context.Context, id, viewerID int64) (bool, error)
+		ReadByPostID(ctx context.Context, postID int64) ([]Media, error)
+		Delete(ctx context.Context, id, userID int64) (*Media, error)
+	}
+	FollowRequests interface {
+		ReadByUserID(ctx context.Context, userID int64, page utils.PaginationQuery) ([]RelatedUser, error)
+		Approve(ctx context.Context, userID, requesterID int64) error
+		Reject(ctx context.Context, userID, requesterID int64) error
+	}
+	Blocks interface {
+		Block(ctx context.Context, blockerID, blockedID int64) error
+		Unblock(ctx context.Context, blockerID, blockedID int64) error
+		IsBlocked(ctx context.Context, userID, otherID int64) (bool, error)
+		ReadBlocked(ctx context.Context, blockerID int64, page utils.PaginationQuery) ([]RelatedUser, error)
+		Mute(ctx context.Context, muterID, mutedID int64) error
+		Unmute(ctx context.Context, muterID, mutedID int64) error
+		ReadMuted(ctx context.Context, muterID int64, page utils.PaginationQuery) ([]RelatedUser, error)
+	}
+	Reactions interface {
+		Add(ctx context.Context, postID, userID int64, reactionType string) error
+		Remove(ctx context.Context, postID, userID int64, reactionType string) error
+		ReadSummary(ctx context.Context, postID, viewerID int64) (ReactionCounts, []string, error)
+		ReadByPostID(ctx context.Context, postID int64, reactionType string, page utils.PaginationQuery) ([]Reaction, error)
+	}
+	Bookmarks interface {
+		Add(ctx context.Context, userID, postID int64, folderID *int64) error
+		Move(ctx context.Context, userID, postID int64, folderID *int64) error
+		Remove(ctx context.Context, userID, postID int64) error
+		ReadByUserID(ctx context.Context, userID int64, filter BookmarkFilter, page utils.PaginationQuery) ([]Bookmark, error)
+		CreateFolder(ctx context.Context, userID int64, name string) (*BookmarkFolder, error)
+		ReadFolders(ctx context.Context, userID int64) ([]BookmarkFolder, error)
+		DeleteFolder(ctx context.Context, userID, folderID int64) error
+	}
+	Reposts interface {
+		Create(ctx context.Context, postID, userID int64) error
+		Delete(ctx context.Context, postID, userID int64) error
+	}
+	RevokedTokens interface {
+		Revoke(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+		IsRevoked(ctx context.Context, jti string) (bool, error)
+		PurgeExpired(ctx context.Context) (int64, error)
+	}
 }
 
-func NewStore(db *sql.DB) *Store {
+func NewStore(db *sql.DB, hasher PasswordHasher) *Store {
 	return &Store{
-		Users:    NewUserStore(db),
-		Posts:    NewPostStore(db),
-		Comments: NewCommentStore(db),
-		Roles:    NewRoleStore(db),
+		Users:                NewUserStore(db, hasher),
+		Posts:                NewPostStore(db),
+		Comments:             NewCommentStore(db),
+		Roles:                NewRoleStore(db),
+		RefreshTokens:        NewRefreshTokenStore(db),
+		RevokedTokens:        NewRevokedTokenStore(db),
+		MFA:                  NewMFAStore(db),
+		PersonalAccessTokens: NewPersonalAccessTokenStore(db),
+		LoginFailures:        NewLoginFailureStore(db),
+		MagicLinks:           NewMagicLinkStore(db),
+		Identities:           NewIdentityStore(db),
+		Sessions:             NewSessionStore(db),
+		EmailChanges:         NewEmailChangeStore(db),
+		Media:                NewMediaStore(db),
+		Blocks:               NewBlockStore(db),
+		FollowRequests:       NewFollowRequestStore(db),
+		Reactions:            NewReactionStore(db),
+		Bookmarks:            NewBookmarkStore(db),
+		Reposts:              NewRepostStore(db),
 	}
 }
 
@@ -63,9 +252,10 @@ func withTx(db *sql.DB, ctx context.Context, fn func(tx *sql.Tx) error) error {
 	}
 
 	if err := fn(tx); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return fmt.Errorf("failed to rollback transaction: %w", err)
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("failed to rollback transaction: %w", rbErr)
 		}
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -3,52 +3,68 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/andras-szesztai/social/internal/utils"
 	"github.com/lib/pq"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type UserStore struct {
-	db *sql.DB
+	db     *sql.DB
+	hasher PasswordHasher
+
+	dummyHashOnce sync.Once
+	dummyHash     []byte
 }
 
-func NewUserStore(db *sql.DB) *UserStore {
-	return &UserStore{db: db}
+func NewUserStore(db *sql.DB, hasher PasswordHasher) *UserStore {
+	return &UserStore{db: db, hasher: hasher}
 }
 
 type User struct {
-	ID          int64     `json:"id" example:"1"`
-	Username    string    `json:"username" example:"john_doe"`
-	Email       string    `json:"email" example:"john.doe@example.com"`
-	Password    password  `json:"-"`
-	CreatedAt   time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
-	UpdatedAt   time.Time `json:"updated_at" example:"2021-01-01T00:00:00Z"`
-	IsActivated bool      `json:"is_activated" example:"true"`
-	RoleID      int64     `json:"role_id" example:"1"`
-	Role        *Role     `json:"role"`
+	ID          int64      `json:"id" example:"1"`
+	Username    string     `json:"username" example:"john_doe"`
+	Email       string     `json:"email" example:"john.doe@example.com"`
+	Password    password   `json:"-"`
+	CreatedAt   time.Time  `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt   time.Time  `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+	IsActivated bool       `json:"is_activated" example:"true"`
+	RoleID      int64      `json:"role_id" example:"1"`
+	Role        *Role      `json:"role"`
+	Stats       *UserStats `json:"stats,omitempty"`
+	// DeactivatedAt is set while the account waits to be purged. Signing in
+	// again before then restores it.
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty" example:"2021-01-01T00:00:00Z"`
+	Profile
 }
 
-type password struct {
-	plaintext *string
-	hash      []byte
+type Profile struct {
+	DisplayName string   `json:"display_name" example:"John Doe"`
+	Bio         string   `json:"bio" example:"Writing about Go and databases"`
+	AvatarURL   string   `json:"avatar_url" example:"https://example.com/avatar.png"`
+	Links       []string `json:"links" example:"https://example.com"`
+	Location    string   `json:"location" example:"Budapest"`
+	// IsPrivate turns follows into requests the user has to approve and
+	// limits their posts to approved followers.
+	IsPrivate bool `json:"is_private" example:"false"`
 }
 
-func (p *password) Set(plaintext string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plaintext), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
-
-	p.plaintext = &plaintext
-	p.hash = hash
-
-	return nil
+// PublicUser is the view of a user shown to other users. It leaves out the
+// email and account details.
+type PublicUser struct {
+	ID           int64         `json:"id" example:"1"`
+	Username     string        `json:"username" example:"john_doe"`
+	CreatedAt    time.Time     `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	Stats        *UserStats    `json:"stats,omitempty"`
+	Relationship *Relationship `json:"relationship,omitempty"`
+	Profile
 }
 
-func (p *password) Compare(plaintext string) error {
-	return bcrypt.CompareHashAndPassword(p.hash, []byte(plaintext))
+func (u *User) Public() PublicUser {
+	return PublicUser{ID: u.ID, Username: u.Username, CreatedAt: u.CreatedAt, Stats: u.Stats, Profile: u.Profile}
 }
 
 func (s *UserStore) Create(ctx context.Context, tx *sql.Tx, user *User) (*User, error) {
@@ -65,6 +81,10 @@ func (s *UserStore) Create(ctx context.Context, tx *sql.Tx, user *User) (*User,
 		role = "user"
 	}
 
+	if err := s.hashPassword(&user.Password); err != nil {
+		return nil, err
+	}
+
 	row := tx.QueryRowContext(ctx, query, user.Username, user.Email, user.Password.hash, role)
 	err := row.Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
@@ -83,10 +103,11 @@ func (s *UserStore) Create(ctx context.Context, tx *sql.Tx, user *User) (*User,
 
 func (s *UserStore) ReadByID(ctx context.Context, id int64) (*User, error) {
 	query := `
-		SELECT id, username, email, password, created_at, updated_at, role_id, roles.name, roles.level, roles.description
+		SELECT users.id, username, email, password, created_at, updated_at, activated, role_id, roles.name, roles.level, roles.description,
+			display_name, bio, avatar_url, links, location, is_private, deactivated_at
 		FROM users
 		JOIN roles ON users.role_id = roles.id
-		WHERE id = $1
+		WHERE users.id = $1
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
@@ -94,8 +115,11 @@ func (s *UserStore) ReadByID(ctx context.Context, id int64) (*User, error) {
 
 	row := s.db.QueryRowContext(ctx, query, id)
 
-	var user User
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password.hash, &user.CreatedAt, &user.UpdatedAt, &user.RoleID, &user.Role.Name, &user.Role.Level, &user.Role.Description)
+	user := User{Role: &Role{}}
+	err := row.Scan(
+		&user.ID, &user.Username, &user.Email, &user.Password.hash, &user.CreatedAt, &user.UpdatedAt, &user.IsActivated, &user.RoleID, &user.Role.Name, &user.Role.Level, &user.Role.Description,
+		&user.DisplayName, &user.Bio, &user.AvatarURL, pq.Array(&user.Links), &user.Location, &user.IsPrivate, &user.DeactivatedAt,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -105,8 +129,10 @@ func (s *UserStore) ReadByID(ctx context.Context, id int64) (*User, error) {
 
 func (s *UserStore) ReadByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, username, email,  password, created_at,updated_at
+		SELECT users.id, username, email, password, created_at, updated_at, activated, role_id, roles.name, roles.level, roles.description,
+			display_name, bio, avatar_url, links, location, is_private, deactivated_at
 		FROM users
+		JOIN roles ON users.role_id = roles.id
 		WHERE email = $1 AND activated = true
 	`
 
@@ -115,8 +141,11 @@ func (s *UserStore) ReadByEmail(ctx context.Context, email string) (*User, error
 
 	row := s.db.QueryRowContext(ctx, query, email)
 
-	var user User
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password.hash, &user.CreatedAt, &user.UpdatedAt)
+	user := User{Role: &Role{}}
+	err := row.Scan(
+		&user.ID, &user.Username, &user.Email, &user.Password.hash, &user.CreatedAt, &user.UpdatedAt, &user.IsActivated, &user.RoleID, &user.Role.Name, &user.Role.Level, &user.Role.Description,
+		&user.DisplayName, &user.Bio, &user.AvatarURL, pq.Array(&user.Links), &user.Location, &user.IsPrivate, &user.DeactivatedAt,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -124,29 +153,140 @@ func (s *UserStore) ReadByEmail(ctx context.Context, email string) (*User, error
 	return &user, nil
 }
 
-func (s *UserStore) Follow(ctx context.Context, userID, followerID int64) error {
+// Follow makes followerID follow userID. When userID is a private account a
+// follow request is created instead and pending is true. It returns
+// ErrSelfFollow, ErrAlreadyFollowing, ErrFollowRequestPending, ErrBlocked
+// when either user blocked the other, or ErrNotFound when either user does
+// not exist.
+func (s *UserStore) Follow(ctx context.Context, userID, followerID int64) (pending bool, err error) {
+	if userID == followerID {
+		return false, ErrSelfFollow
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	err = withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT u.is_private, EXISTS (
+				SELECT 1 FROM user_blocks
+				WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+			)
+			FROM users u
+			WHERE u.id = $1
+			FOR SHARE
+		`
+
+		var blocked bool
+		if err := tx.QueryRowContext(ctx, query, userID, followerID).Scan(&pending, &blocked); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if blocked {
+			return ErrBlocked
+		}
+
+		if !pending {
+			query = `
+				INSERT INTO followers (user_id, follower_id)
+				VALUES ($1, $2)
+			`
+
+			_, err := tx.ExecContext(ctx, query, userID, followerID)
+			switch pgErrorCode(err) {
+			case pgUniqueViolation:
+				return ErrAlreadyFollowing
+			case pgForeignKeyViolation:
+				return ErrNotFound
+			}
+			return err
+		}
+
+		query = `
+			INSERT INTO follow_requests (user_id, requester_id)
+			SELECT $1, $2
+			WHERE NOT EXISTS (SELECT 1 FROM followers WHERE user_id = $1 AND follower_id = $2)
+		`
+
+		result, err := tx.ExecContext(ctx, query, userID, followerID)
+		switch pgErrorCode(err) {
+		case pgUniqueViolation:
+			return ErrFollowRequestPending
+		case pgForeignKeyViolation:
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return ErrAlreadyFollowing
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return pending, nil
+}
+
+// Unfollow removes the relationship or withdraws a pending follow request,
+// returning ErrNotFollowing when there was neither.
+func (s *UserStore) Unfollow(ctx context.Context, userID, followerID int64) error {
 	query := `
-		INSERT INTO followers (user_id, follower_id)
-		VALUES ($1, $2)
+		WITH unfollowed AS (
+			DELETE FROM followers WHERE user_id = $1 AND follower_id = $2
+			RETURNING 1
+		), withdrawn AS (
+			DELETE FROM follow_requests WHERE user_id = $1 AND requester_id = $2
+			RETURNING 1
+		)
+		SELECT (SELECT COUNT(*) FROM unfollowed) + (SELECT COUNT(*) FROM withdrawn)
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	_, err := s.db.ExecContext(ctx, query, userID, followerID)
-	return err
+	var deleted int64
+	if err := s.db.QueryRowContext(ctx, query, userID, followerID).Scan(&deleted); err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFollowing
+	}
+
+	return nil
 }
 
-func (s *UserStore) Unfollow(ctx context.Context, userID, followerID int64) error {
+// CanViewPosts reports whether viewerID may read ownerID's posts: the owner
+// is not deactivated, neither blocked the other and the owner is public, the
+// viewer, or followed by the viewer. Posts of purged accounts that were kept
+// stay readable.
+func (s *UserStore) CanViewPosts(ctx context.Context, ownerID, viewerID int64) (bool, error) {
 	query := `
-		DELETE FROM followers WHERE user_id = $1 AND follower_id = $2
+		SELECT ` + visibleToClause("u", "$2") + `
+		FROM users u
+		WHERE u.id = $1
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	_, err := s.db.ExecContext(ctx, query, userID, followerID)
-	return err
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, query, ownerID, viewerID).Scan(&allowed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	return allowed, err
 }
 
 type UserFeed struct {
@@ -158,24 +298,78 @@ type UserFeed struct {
 	CreatedAt    time.Time `json:"created_at"`
 	Tags         []string  `json:"tags"`
 	CommentCount int64     `json:"comment_count"`
+	RepostCount  int64     `json:"repost_count"`
+	Edited       bool      `json:"edited"`
+	// Reactions counts the post's reactions by type and MyReactions lists
+	// the types the feed's owner reacted with.
+	Reactions   ReactionCounts `json:"reactions" swaggertype:"object,integer" example:"like:3"`
+	MyReactions []string       `json:"my_reactions" example:"like"`
+	// RepostedBy is set when the post is in the feed because someone
+	// reposted it. UserID and Username still name the original author.
+	RepostedBy *Reposter `json:"reposted_by,omitempty"`
+	// QuotedPost is the post this one quotes, left out when the feed's
+	// owner cannot see it.
+	QuotedPost *QuotedPost `json:"quoted_post,omitempty"`
 }
 
+type Reposter struct {
+	UserID     int64     `json:"user_id"`
+	Username   string    `json:"username"`
+	RepostedAt time.Time `json:"reposted_at"`
+}
+
+type QuotedPost struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReadFeed lists published posts by userID and the users they follow, and
+// posts those users reposted. A post reposted several times, or also written by someone
+// followed, shows up once at its most recent activity.
 func (s *UserStore) ReadFeed(ctx context.Context, userID int64, fq utils.FeedQuery) ([]UserFeed, error) {
 	query := `
+		WITH entries AS (
+			SELECT p.id AS post_id, NULL::bigint AS reposted_by, p.published_at AS activity_at
+			FROM posts p
+			WHERE p.status = 'published' AND
+				(p.user_id = $1 OR EXISTS (SELECT 1 FROM followers f WHERE f.user_id = p.user_id AND f.follower_id = $1))
+			UNION ALL
+			SELECT r.post_id, r.user_id, r.created_at
+			FROM reposts r
+			JOIN users ru ON ru.id = r.user_id
+			WHERE
+				(r.user_id = $1 OR EXISTS (SELECT 1 FROM followers f WHERE f.user_id = r.user_id AND f.follower_id = $1)) AND
+				` + visibleToClause("ru", "$1") + ` AND
+				NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.muter_id = $1 AND m.muted_id = r.user_id)
+		), latest AS (
+			SELECT DISTINCT ON (post_id) post_id, reposted_by, activity_at
+			FROM entries
+			ORDER BY post_id, activity_at DESC, reposted_by NULLS FIRST
+		)
 		SELECT 
 			p.id, p.user_id, p.title, p.content, p.created_at, p.tags,
-			COUNT(c.id) as comment_count,
-			u.username
-		FROM posts p
-		LEFT JOIN comments c ON c.post_id = p.id
-		LEFT JOIN users u ON p.user_id = u.id
-		LEFT JOIN followers f ON f.user_id = p.user_id AND f.follower_id = $1
+			(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count,
+			(SELECT COUNT(*) FROM reposts r WHERE r.post_id = p.id) AS repost_count,
+			` + editedAtColumn("p") + ` IS NOT NULL AS edited,
+			u.username,` + reactionColumns("p.id", "$1") + `,
+			ru.id, ru.username, l.activity_at,
+			q.id, q.user_id, qu.username, q.title, q.content, q.created_at
+		FROM latest l
+		JOIN posts p ON p.id = l.post_id
+		JOIN users u ON u.id = p.user_id
+		LEFT JOIN users ru ON ru.id = l.reposted_by
+		LEFT JOIN posts q ON q.id = p.quoted_post_id AND q.status = 'published'
+		LEFT JOIN users qu ON qu.id = q.user_id AND ` + visibleToClause("qu", "$1") + `
 		WHERE 
-			(p.user_id = $1 OR f.follower_id = $1) AND 
 			(p.title ILIKE '%' || $2 || '%' OR p.content ILIKE '%' || $2 || '%') AND
-			(p.tags @> $3 OR $3 = '{}')
-		GROUP BY p.id, u.username
-		ORDER BY p.created_at ` + fq.Sort + ` 
+			(p.tags @> $3 OR $3 = '{}') AND
+			` + visibleToClause("u", "$1") + ` AND
+			NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.muter_id = $1 AND m.muted_id = p.user_id)
+		ORDER BY l.activity_at ` + fq.Sort + `, p.id ` + fq.Sort + `
 		OFFSET $4 LIMIT $5	
 	`
 
@@ -191,9 +385,40 @@ func (s *UserStore) ReadFeed(ctx context.Context, userID int64, fq utils.FeedQue
 	var feed []UserFeed
 	for rows.Next() {
 		var item UserFeed
-		if err := rows.Scan(&item.ID, &item.UserID, &item.Title, &item.Content, &item.CreatedAt, pq.Array(&item.Tags), &item.CommentCount, &item.Username); err != nil {
+		var reposterID *int64
+		var reposter *string
+		var activityAt time.Time
+		var quoted struct {
+			id, userID     *int64
+			username       *string
+			title, content *string
+			createdAt      *time.Time
+		}
+		err := rows.Scan(
+			&item.ID, &item.UserID, &item.Title, &item.Content, &item.CreatedAt, pq.Array(&item.Tags), &item.CommentCount, &item.RepostCount, &item.Edited, &item.Username,
+			&item.Reactions, pq.Array(&item.MyReactions),
+			&reposterID, &reposter, &activityAt,
+			&quoted.id, &quoted.userID, &quoted.username, &quoted.title, &quoted.content, &quoted.createdAt,
+		)
+		if err != nil {
 			return nil, err
 		}
+
+		if reposterID != nil {
+			item.RepostedBy = &Reposter{UserID: *reposterID, Username: *reposter, RepostedAt: activityAt}
+		}
+		// The quoted author is only joined when the viewer may see them.
+		if quoted.username != nil {
+			item.QuotedPost = &QuotedPost{
+				ID:        *quoted.id,
+				UserID:    *quoted.userID,
+				Username:  *quoted.username,
+				Title:     *quoted.title,
+				Content:   *quoted.content,
+				CreatedAt: *quoted.createdAt,
+			}
+		}
+
 		feed = append(feed, item)
 	}
 
@@ -204,6 +429,19 @@ func (s *UserStore) ReadFeed(ctx context.Context, userID int64, fq utils.FeedQue
 	return feed, nil
 }
 
+// visibleToClause is the SQL condition under which the posts of the user
+// aliased as author are visible to viewerID, see CanViewPosts.
+func visibleToClause(author, viewerID string) string {
+	return fmt.Sprintf(`(
+		(%[1]s.deactivated_at IS NULL OR %[1]s.anonymized_at IS NOT NULL) AND
+		(NOT %[1]s.is_private OR %[1]s.id = %[2]s OR EXISTS (SELECT 1 FROM followers vf WHERE vf.user_id = %[1]s.id AND vf.follower_id = %[2]s)) AND
+		NOT EXISTS (
+			SELECT 1 FROM user_blocks vb
+			WHERE (vb.blocker_id = %[2]s AND vb.blocked_id = %[1]s.id) OR (vb.blocker_id = %[1]s.id AND vb.blocked_id = %[2]s)
+		)
+	)`, author, viewerID)
+}
+
 func (s *UserStore) CreateAndInvite(ctx context.Context, user *User, token string, invitationExpiry time.Duration) error {
 	return withTx(s.db, ctx, func(tx *sql.Tx) error {
 
@@ -229,6 +467,34 @@ func (s *UserStore) CreateAndInvite(ctx context.Context, user *User, token strin
 	})
 }
 
+// CreateWithIdentity creates an already activated user signing up through an
+// external identity provider, which has verified the email, and links the
+// identity to it.
+func (s *UserStore) CreateWithIdentity(ctx context.Context, user *User, identity *Identity) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		user, err := s.Create(ctx, tx, user)
+		if err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE users SET activated = true WHERE id = $1
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		_, err = tx.ExecContext(ctx, query, user.ID)
+		if err != nil {
+			return err
+		}
+		user.IsActivated = true
+
+		identity.UserID = user.ID
+		return createIdentity(ctx, tx, identity)
+	})
+}
+
 func (s *UserStore) Activate(ctx context.Context, userID int64, token string) error {
 	return withTx(s.db, ctx, func(tx *sql.Tx) error {
 		query := `
@@ -277,6 +543,107 @@ func (s *UserStore) Activate(ctx context.Context, userID int64, token string) er
 	})
 }
 
+// RotateInvitation replaces every pending invitation of the not yet activated
+// user with the given email by a new one. It returns ErrInvitationCooldown
+// when the last invitation was sent less than cooldown ago.
+func (s *UserStore) RotateInvitation(ctx context.Context, email, token string, invitationExpiry, cooldown time.Duration) (*User, error) {
+	var user User
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT id, username, email, created_at
+			FROM users
+			WHERE email = $1 AND activated = false
+			FOR UPDATE
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		err := tx.QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			SELECT EXISTS (
+				SELECT 1 FROM user_invitations
+				WHERE user_id = $1 AND created_at > now() - make_interval(secs => $2)
+			)
+		`
+
+		var recent bool
+		err = tx.QueryRowContext(ctx, query, user.ID, cooldown.Seconds()).Scan(&recent)
+		if err != nil {
+			return err
+		}
+		if recent {
+			return ErrInvitationCooldown
+		}
+
+		query = `
+			DELETE FROM user_invitations WHERE user_id = $1
+		`
+
+		_, err = tx.ExecContext(ctx, query, user.ID)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			INSERT INTO user_invitations (user_id, token, expires_at)
+			VALUES ($1, $2, $3)
+		`
+
+		_, err = tx.ExecContext(ctx, query, user.ID, token, time.Now().Add(invitationExpiry))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// PurgeUnactivated deletes expired invitations and accounts that were never
+// activated within grace of signing up. It returns how many invitations and
+// users were removed.
+func (s *UserStore) PurgeUnactivated(ctx context.Context, grace time.Duration) (int64, int64, error) {
+	var invitations, users int64
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM user_invitations WHERE expires_at < now()
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		result, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		if invitations, err = result.RowsAffected(); err != nil {
+			return err
+		}
+
+		query = `
+			DELETE FROM users
+			WHERE activated = false AND created_at < now() - make_interval(secs => $1)
+		`
+
+		result, err = tx.ExecContext(ctx, query, grace.Seconds())
+		if err != nil {
+			return err
+		}
+		users, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return invitations, users, nil
+}
+
 func (s *UserStore) Delete(ctx context.Context, id int64) error {
 	return withTx(s.db, ctx, func(tx *sql.Tx) error {
 		query := `
@@ -291,3 +658,250 @@ func (s *UserStore) Delete(ctx context.Context, id int64) error {
 		return nil
 	})
 }
+
+// SetAvatar points the user's avatar at an uploaded image, which makes the
+// image publicly downloadable for as long as it stays the avatar.
+func (s *UserStore) SetAvatar(ctx context.Context, user *User, mediaID int64) error {
+	query := `
+		UPDATE users SET avatar_url = $1, avatar_media_id = $2, updated_at = now()
+		WHERE id = $3
+		RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return s.db.QueryRowContext(ctx, query, user.AvatarURL, mediaID, user.ID).Scan(&user.UpdatedAt)
+}
+
+// UpdateProfile saves the profile. Making an account public approves its
+// pending follow requests.
+func (s *UserStore) UpdateProfile(ctx context.Context, user *User) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE users
+			SET display_name = $1, bio = $2, avatar_url = $3, links = $4, location = $5, is_private = $6, updated_at = now(),
+				avatar_media_id = CASE WHEN avatar_url = $3 THEN avatar_media_id END
+			WHERE id = $7
+			RETURNING updated_at
+		`
+
+		row := tx.QueryRowContext(ctx, query, user.DisplayName, user.Bio, user.AvatarURL, pq.Array(user.Links), user.Location, user.IsPrivate, user.ID)
+		if err := row.Scan(&user.UpdatedAt); err != nil {
+			return err
+		}
+
+		if user.IsPrivate {
+			return nil
+		}
+
+		query = `
+			WITH approved AS (
+				DELETE FROM follow_requests WHERE user_id = $1
+				RETURNING user_id, requester_id
+			)
+			INSERT INTO followers (user_id, follower_id)
+			SELECT user_id, requester_id FROM approved
+			ON CONFLICT DO NOTHING
+		`
+
+		_, err := tx.ExecContext(ctx, query, user.ID)
+		return err
+	})
+}
+
+func (s *UserStore) UpdatePassword(ctx context.Context, user *User) error {
+	query := `
+		UPDATE users SET password = $1, updated_at = now() WHERE id = $2
+	`
+
+	if err := s.hashPassword(&user.Password); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, user.Password.hash, user.ID)
+	return err
+}
+
+// RehashPassword saves a fresh hash when the stored one was made with another
+// algorithm or other parameters than the configured hasher. It is called
+// after a successful Compare, which keeps the plaintext to hash.
+func (s *UserStore) RehashPassword(ctx context.Context, user *User) error {
+	if user.Password.plaintext == nil || !s.hasher.NeedsRehash(user.Password.hash) {
+		return nil
+	}
+
+	user.Password.Set(*user.Password.plaintext)
+	return s.UpdatePassword(ctx, user)
+}
+
+// CompareDummyPassword runs a comparison against a fixed hash made by the
+// configured hasher. Logins for unknown accounts call it so they take about
+// as long to reject as a wrong password and do not reveal which emails are
+// registered.
+func (s *UserStore) CompareDummyPassword(plaintext string) {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = s.hasher.Hash("dummy password for unknown accounts")
+	})
+
+	probe := password{hash: s.dummyHash}
+	_ = probe.Compare(plaintext)
+}
+
+// hashPassword hashes a password recorded with Set. Passwords read from the
+// database keep their stored hash.
+func (s *UserStore) hashPassword(p *password) error {
+	if p.plaintext == nil || p.hash != nil {
+		return nil
+	}
+
+	hash, err := s.hasher.Hash(*p.plaintext)
+	if err != nil {
+		return err
+	}
+	p.hash = hash
+
+	return nil
+}
+
+func (s *UserStore) ReadTokenGeneration(ctx context.Context, id int64) (int64, error) {
+	query := `
+		SELECT token_generation FROM users WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var generation int64
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&generation)
+	if err != nil {
+		return 0, err
+	}
+
+	return generation, nil
+}
+
+func (s *UserStore) IncrementTokenGeneration(ctx context.Context, id int64) (int64, error) {
+	query := `
+		UPDATE users SET token_generation = token_generation + 1
+		WHERE id = $1
+		RETURNING token_generation
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var generation int64
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&generation)
+	if err != nil {
+		return 0, err
+	}
+
+	return generation, nil
+}
+
+func (s *UserStore) CreatePasswordReset(ctx context.Context, userID int64, token string, expiry time.Duration) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM password_resets WHERE user_id = $1
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		_, err := tx.ExecContext(ctx, query, userID)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			INSERT INTO password_resets (user_id, token, expires_at)
+			VALUES ($1, $2, $3)
+		`
+
+		_, err = tx.ExecContext(ctx, query, userID, token, time.Now().Add(expiry))
+		return err
+	})
+}
+
+// ReadPasswordReset returns the user a reset token was issued to. It returns
+// ErrNotFound for unknown tokens and ErrPasswordResetExpired for stale ones.
+func (s *UserStore) ReadPasswordReset(ctx context.Context, token string) (int64, error) {
+	query := `
+		SELECT user_id, expires_at
+		FROM password_resets
+		WHERE token = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var userID int64
+	var expiresAt time.Time
+	err := s.db.QueryRowContext(ctx, query, token).Scan(&userID, &expiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	if time.Now().After(expiresAt) {
+		return 0, ErrPasswordResetExpired
+	}
+
+	return userID, nil
+}
+
+// ResetPassword replaces the password of the user the reset token was issued
+// to with user.Password and consumes every outstanding reset token of that
+// user. On success user.ID is set to the affected user.
+func (s *UserStore) ResetPassword(ctx context.Context, token string, user *User) error {
+	if err := s.hashPassword(&user.Password); err != nil {
+		return err
+	}
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT user_id, expires_at
+			FROM password_resets
+			WHERE token = $1
+		`
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		var expiresAt time.Time
+		err := tx.QueryRowContext(ctx, query, token).Scan(&user.ID, &expiresAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+		if time.Now().After(expiresAt) {
+			return ErrPasswordResetExpired
+		}
+
+		query = `
+			UPDATE users SET password = $1, updated_at = now() WHERE id = $2
+		`
+
+		_, err = tx.ExecContext(ctx, query, user.Password.hash, user.ID)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			DELETE FROM password_resets WHERE user_id = $1
+		`
+
+		_, err = tx.ExecContext(ctx, query, user.ID)
+		return err
+	})
+}
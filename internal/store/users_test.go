@@ -0,0 +1,21 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUserStoreFollow(t *testing.T) {
+	t.Run("should reject following yourself", func(t *testing.T) {
+		// The check runs before the database is touched.
+		s := NewUserStore(nil, nil)
+
+		pending, err := s.Follow(context.Background(), 1, 1)
+		if err != ErrSelfFollow {
+			t.Errorf("expected %v, got %v", ErrSelfFollow, err)
+		}
+		if pending {
+			t.Error("expected no pending follow request")
+		}
+	})
+}
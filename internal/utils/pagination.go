@@ -54,3 +54,32 @@ func (fq FeedQuery) Parse(r *http.Request) (FeedQuery, error) {
 
 	return fq, nil
 }
+
+type PaginationQuery struct {
+	Limit  int `json:"limit" validate:"gte=1,lte=100"`
+	Offset int `json:"offset" validate:"gte=0"`
+}
+
+func (pq PaginationQuery) Parse(r *http.Request) (PaginationQuery, error) {
+	query := r.URL.Query()
+
+	limit := query.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return pq, err
+		}
+		pq.Limit = l
+	}
+
+	offset := query.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return pq, err
+		}
+		pq.Offset = o
+	}
+
+	return pq, nil
+}